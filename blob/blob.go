@@ -0,0 +1,42 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotFound is returned by Get when no object exists under the key.
+var ErrNotFound = errors.New("blob not found")
+
+// Storage stores opaque objects under slash separated keys.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Config selects and configures the storage backend.
+type Config struct {
+	// Backend is "local", the default, or "s3".
+	Backend  string
+	LocalDir string
+	S3       S3Config
+}
+
+// New builds the Storage selected by cfg.Backend.
+func New(cfg Config) (Storage, error) {
+	switch cfg.Backend {
+	case "", "local":
+		dir := cfg.LocalDir
+		if dir == "" {
+			dir = "data/attachments"
+		}
+		return NewLocal(dir)
+	case "s3":
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q", cfg.Backend)
+	}
+}
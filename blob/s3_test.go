@@ -0,0 +1,105 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testS3 connects to the MinIO container of docker-compose, or to the
+// S3_TEST_* endpoint, and skips the test when nothing is listening.
+func testS3(t *testing.T) *S3 {
+	t.Helper()
+	config := S3Config{
+		Endpoint:  envOr("S3_TEST_ENDPOINT", "http://localhost:9000"),
+		Region:    envOr("S3_TEST_REGION", "us-east-1"),
+		Bucket:    envOr("S3_TEST_BUCKET", "carm-test"),
+		AccessKey: envOr("S3_TEST_ACCESS_KEY", "minioadmin"),
+		SecretKey: envOr("S3_TEST_SECRET_KEY", "minioadmin"),
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		t.Fatalf("invalid S3_TEST_ENDPOINT: %v", err)
+	}
+	conn, err := net.DialTimeout("tcp", endpoint.Host, time.Second)
+	if err != nil {
+		t.Skipf("no S3 stand-in at %s: %v", config.Endpoint, err)
+	}
+	conn.Close()
+
+	s, err := NewS3(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, strings.TrimSuffix(config.Endpoint, "/")+"/"+config.Bucket, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An existing bucket answers 409 Conflict.
+	resp, err := s.do(req)
+	if err != nil && !strings.Contains(err.Error(), "409 Conflict") {
+		t.Fatalf("creating bucket %s: %v", config.Bucket, err)
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return s
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	s := testS3(t)
+	ctx := context.Background()
+	key := "cars/" + time.Now().Format("20060102T150405.000000000") + "/file name+ä.txt"
+	content := "hello from carm"
+
+	if err := s.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	body, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		t.Fatalf("reading object: %v", err)
+	}
+	if string(got) != content {
+		t.Fatalf("Get returned %q, want %q", got, content)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete returned %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3MissingObject(t *testing.T) {
+	s := testS3(t)
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, "missing/object"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get returned %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "missing/object"); err != nil {
+		t.Fatalf("Delete of a missing object returned %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+// Package cache keeps encoded values for a while, in an in-process LRU or
+// in Redis. It is used by the caching stores in store/cached.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+	BackendNone   = "none"
+
+	// DefaultSize is the number of entries the memory backend keeps.
+	DefaultSize = 10000
+	// DefaultTTL is how long values are kept.
+	DefaultTTL = 5 * time.Minute
+)
+
+// Cache maps keys to values until they expire or are deleted. Get reports
+// whether key was found.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// Run maintains the cache, such as removing expired entries, until ctx
+	// is cancelled.
+	Run(ctx context.Context)
+}
+
+// Config selects the backend of New.
+type Config struct {
+	// Backend is "memory", the default, "redis" or "none".
+	Backend string
+	// Size bounds the entries of the memory backend.
+	Size int
+	// RedisURL is a redis:// or rediss:// URL for the redis backend.
+	RedisURL string
+}
+
+// New builds the Cache selected by cfg. The none backend returns nil: the
+// caching stores are then left out.
+func New(cfg Config) (Cache, error) {
+	switch cfg.Backend {
+	case "", BackendMemory:
+		size := cfg.Size
+		if size <= 0 {
+			size = DefaultSize
+		}
+		return NewLRU(size), nil
+	case BackendRedis:
+		options, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis URL: %w", err)
+		}
+		return NewRedis(redis.NewClient(options)), nil
+	case BackendNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// cleanupInterval is how often expired entries are removed.
+const cleanupInterval = time.Minute
+
+// LRU keeps up to size entries in this process and evicts the least
+// recently used one when it is full.
+type LRU struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func NewLRU(size int) *LRU {
+	return &LRU{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *LRU) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*lruEntry)
+	if !time.Now().Before(entry.expires) {
+		c.remove(element)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (c *LRU) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &lruEntry{key: key, value: value, expires: time.Now().Add(ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *LRU) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+// Run removes expired entries every minute until ctx is cancelled, so
+// that they do not hold memory until they are evicted.
+func (c *LRU) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			for _, element := range c.entries {
+				if !now.Before(element.Value.(*lruEntry).expires) {
+					c.remove(element)
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *LRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "carm:cache:"
+
+// Redis keeps the values in Redis, or a server speaking its protocol such
+// as Valkey, so that all instances share them and see each other's
+// invalidations.
+type Redis struct {
+	client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, redisKeyPrefix+key, value, ttl).Err()
+}
+
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = redisKeyPrefix + key
+	}
+	return c.client.Del(ctx, prefixed...).Err()
+}
+
+// Run closes the client once ctx is cancelled; Redis expires the values
+// itself.
+func (c *Redis) Run(ctx context.Context) {
+	<-ctx.Done()
+	if err := c.client.Close(); err != nil {
+		logging.FromContext(ctx).Error("Error closing redis client", "error", err)
+	}
+}
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+// GetCarById returns a zero Car when there is no car with the id, like the
+// service does.
+func (c *Client) GetCarById(ctx context.Context, id string) (models.Car, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "GetCarById-Client")
+	defer span.End()
+
+	var car models.Car
+	err := c.do(ctx, request{method: http.MethodGet, path: "/cars/" + url.PathEscape(id)}, &car)
+	return car, err
+}
+
+func (c *Client) GetCars(ctx context.Context, filter models.CarFilter) ([]models.Car, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "GetCars-Client")
+	defer span.End()
+
+	var cars []models.Car
+	err := c.do(ctx, request{method: http.MethodGet, path: "/cars", query: carFilterQuery(filter)}, &cars)
+	return cars, err
+}
+
+// GetCarsByEngineIds lists the cars of one engine at a time, as the REST
+// API has no batched listing.
+func (c *Client) GetCarsByEngineIds(ctx context.Context, engineIds []string, limit int, offset int) ([]models.Car, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "GetCarsByEngineIds-Client")
+	defer span.End()
+
+	var cars []models.Car
+	for _, engineId := range engineIds {
+		id, err := uuid.Parse(engineId)
+		if err != nil {
+			return nil, err
+		}
+		page, err := c.GetCars(ctx, models.CarFilter{EngineID: &id, Limit: limit, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		cars = append(cars, page...)
+	}
+	return cars, nil
+}
+
+func (c *Client) CreateCar(ctx context.Context, carReq *models.CarRequest) (models.Car, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "CreateCar-Client")
+	defer span.End()
+
+	var car models.Car
+	err := c.do(ctx, request{method: http.MethodPost, path: "/cars", body: carReq, idempotent: true, idempotencyKey: newIdempotencyKey()}, &car)
+	return car, err
+}
+
+func (c *Client) UpdateCar(ctx context.Context, id string, carReq *models.CarRequest) (models.Car, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "UpdateCar-Client")
+	defer span.End()
+
+	var car models.Car
+	err := c.do(ctx, request{method: http.MethodPut, path: "/cars/" + url.PathEscape(id), body: carReq}, &car)
+	return car, err
+}
+
+func (c *Client) DeleteCar(ctx context.Context, id string) (models.Car, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "DeleteCar-Client")
+	defer span.End()
+
+	var car models.Car
+	err := c.do(ctx, request{method: http.MethodDelete, path: "/cars/" + url.PathEscape(id)}, &car)
+	return car, err
+}
+
+// RunCarBatch returns the results of a rolled back atomic batch with
+// Committed set to false rather than as an error, like the service does.
+func (c *Client) RunCarBatch(ctx context.Context, batchReq *models.CarBatchRequest) (models.CarBatchResponse, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "RunCarBatch-Client")
+	defer span.End()
+
+	var res models.CarBatchResponse
+	err := c.do(ctx, request{
+		method:         http.MethodPost,
+		path:           "/cars/batch",
+		body:           batchReq,
+		idempotent:     true,
+		idempotencyKey: newIdempotencyKey(),
+		accept:         []int{http.StatusUnprocessableEntity},
+	}, &res)
+	return res, err
+}
+
+func carFilterQuery(filter models.CarFilter) url.Values {
+	query := url.Values{}
+	if filter.Brand != "" {
+		query.Set("brand", filter.Brand)
+	}
+	if filter.IsEngine {
+		query.Set("isEngine", "true")
+	}
+	if filter.MinMileage != nil {
+		query.Set("min_mileage", strconv.FormatInt(*filter.MinMileage, 10))
+	}
+	if filter.MaxMileage != nil {
+		query.Set("max_mileage", strconv.FormatInt(*filter.MaxMileage, 10))
+	}
+	if filter.LocationID != nil {
+		query.Set("location_id", filter.LocationID.String())
+	}
+	if filter.EngineID != nil {
+		query.Set("engine_id", filter.EngineID.String())
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		query.Set("offset", strconv.Itoa(filter.Offset))
+	}
+	return query
+}
@@ -0,0 +1,403 @@
+// Package client is a typed Go client for the carm HTTP API. Its methods
+// mirror the car and engine services, so code written against
+// service.CarServiceInterface or service.EngineServiceInterface can talk
+// to a remote carm instead:
+//
+//	c, err := client.New("http://localhost:8080", client.WithCredentials("admin", "admin"))
+//	cars, err := c.GetCars(ctx, models.CarFilter{Brand: "Honda"})
+//
+// The client signs in through /login on the first call and again before
+// the token expires, unless it is given an API key with WithAPIKey. It
+// retries failed requests with exponential backoff and sends the trace
+// context of ctx along with every request.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultMaxRetries = 3
+	DefaultMinBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+
+	// tokenRefreshMargin renews the token this long before it expires.
+	tokenRefreshMargin = time.Minute
+	maxErrorBodyLength = 4 << 10
+)
+
+var (
+	_ service.CarServiceInterface    = (*Client)(nil)
+	_ service.EngineServiceInterface = (*Client)(nil)
+)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	propagator propagation.TextMapPropagator
+
+	username string
+	password string
+	tenant   string
+	apiKey   string
+
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+type Option func(*Client)
+
+// WithCredentials sets the username and password the client signs in
+// with.
+func WithCredentials(username string, password string) Option {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithAPIKey authenticates every request with an API key instead of a
+// token.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithTenant signs in to the tenant with this id instead of the default
+// tenant.
+func WithTenant(tenantId string) Option {
+	return func(c *Client) {
+		c.tenant = tenantId
+	}
+}
+
+// WithToken uses a token obtained elsewhere. It is replaced through
+// /login once it expires if credentials are set as well.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+		c.tokenExpiry = tokenExpiry(token)
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how often a failed request is retried; 0 disables
+// retries.
+func WithRetries(maxRetries int) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff sets the delay before the first retry, which doubles with
+// every further retry up to max.
+func WithBackoff(min time.Duration, max time.Duration) Option {
+	return func(c *Client) {
+		c.minBackoff = min
+		c.maxBackoff = max
+	}
+}
+
+// WithPropagator sets how the trace context is written into request
+// headers. The default sends W3C trace context and baggage headers.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *Client) {
+		c.propagator = propagator
+	}
+}
+
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base url %q: scheme must be http or https", baseURL)
+	}
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+		maxRetries: DefaultMaxRetries,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.token == "" && c.username == "" && c.apiKey == "" {
+		return nil, errors.New("either credentials, a token or an API key are required")
+	}
+	return c, nil
+}
+
+// request describes one API call.
+type request struct {
+	method string
+	// path is escaped already.
+	path  string
+	query url.Values
+	body  interface{}
+	// idempotent calls may be retried. POST requests are made idempotent
+	// with an Idempotency-Key that stays the same across the retries.
+	idempotent     bool
+	idempotencyKey string
+	// accept lists statuses besides 2xx whose body is decoded into the
+	// result rather than turned into an error.
+	accept []int
+}
+
+// do sends the request, retrying it where that is safe, and decodes the
+// response body into result.
+func (c *Client) do(ctx context.Context, req request, result interface{}) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		body, err = json.Marshal(req.body)
+		if err != nil {
+			return err
+		}
+	}
+	if req.method == http.MethodGet || req.method == http.MethodPut || req.method == http.MethodDelete {
+		req.idempotent = true
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		res, err := c.send(ctx, req, body)
+		if err == nil && res.StatusCode == http.StatusUnauthorized && !refreshed && c.username != "" && c.apiKey == "" {
+			// The token may have been revoked or signed with a rotated
+			// key; sign in again once.
+			drain(res)
+			c.invalidateToken()
+			refreshed = true
+			attempt--
+			continue
+		}
+		retry, delay := c.shouldRetry(ctx, req, attempt, res, err)
+		if !retry {
+			if err != nil {
+				return err
+			}
+			return decodeResponse(req, res, result)
+		}
+		if res != nil {
+			drain(res)
+		}
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request, body []byte) (*http.Response, error) {
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("X-API-Key", c.apiKey)
+	} else {
+		token, err := c.getToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	if req.idempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", req.idempotencyKey)
+	}
+	c.propagator.Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+	return c.httpClient.Do(httpReq)
+}
+
+// shouldRetry retries network errors and responses that say the server is
+// overloaded or temporarily unavailable.
+func (c *Client) shouldRetry(ctx context.Context, req request, attempt int, res *http.Response, err error) (bool, time.Duration) {
+	if attempt >= c.maxRetries || !req.idempotent {
+		return false, 0
+	}
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) || ctx.Err() != nil {
+			// Failed sign-ins and cancelled calls are final.
+			return false, 0
+		}
+		return true, c.backoff(attempt)
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		delay := c.backoff(attempt)
+		if retryAfter, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && retryAfter >= 0 {
+			delay = time.Duration(retryAfter) * time.Second
+		}
+		return true, delay
+	}
+	return false, 0
+}
+
+// backoff returns the delay before retry number attempt+1 with up to 50%
+// jitter, so that clients failing together do not retry together.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.minBackoff
+	for i := 0; i < attempt && delay < c.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > c.maxBackoff {
+		delay = c.maxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func decodeResponse(req request, res *http.Response, result interface{}) error {
+	defer drain(res)
+	accepted := res.StatusCode >= 200 && res.StatusCode <= 299
+	for _, status := range req.accept {
+		accepted = accepted || res.StatusCode == status
+	}
+	if !accepted {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodyLength))
+		return &APIError{
+			Method:     req.method,
+			Path:       req.path,
+			StatusCode: res.StatusCode,
+			Message:    strings.TrimSpace(string(message)),
+		}
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+		return fmt.Errorf("decoding response of %s %s: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+func drain(res *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	res.Body.Close()
+}
+
+// getToken returns the current token, signing in when there is none yet or
+// it is about to expire.
+func (c *Client) getToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Without credentials a token is used until the server rejects it.
+	if c.token != "" && (c.username == "" || c.tokenExpiry.IsZero() || time.Until(c.tokenExpiry) > tokenRefreshMargin) {
+		return c.token, nil
+	}
+
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "Login-Client")
+	defer span.End()
+
+	body, err := json.Marshal(models.Credentials{Username: c.username, Password: c.password, Tenant: c.tenant})
+	if err != nil {
+		return "", err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/login", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	c.propagator.Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+	res, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	var login struct {
+		Token string `json:"token"`
+	}
+	if err := decodeResponse(request{method: http.MethodPost, path: "/login"}, res, &login); err != nil {
+		return "", err
+	}
+	if login.Token == "" {
+		return "", errors.New("login response has no token")
+	}
+	c.token = login.Token
+	c.tokenExpiry = tokenExpiry(login.Token)
+	return c.token, nil
+}
+
+func (c *Client) invalidateToken() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+	c.tokenExpiry = time.Time{}
+}
+
+// tokenExpiry reads the exp claim of a JWT without verifying it; the
+// server does that. Tokens without one get a zero time.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.ExpiresAt, 0)
+}
+
+func newIdempotencyKey() string {
+	return uuid.NewString()
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Akmyrat17/carm/models"
+	"go.opentelemetry.io/otel"
+)
+
+// GetEngineById returns a zero Engine when there is no engine with the id,
+// like the service does.
+func (c *Client) GetEngineById(ctx context.Context, id string) (models.Engine, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "GetEngineById-Client")
+	defer span.End()
+
+	var engine models.Engine
+	err := c.do(ctx, request{method: http.MethodGet, path: "/engines/" + url.PathEscape(id)}, &engine)
+	return engine, err
+}
+
+func (c *Client) GetEngines(ctx context.Context) ([]models.Engine, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "GetEngines-Client")
+	defer span.End()
+
+	var engines []models.Engine
+	err := c.do(ctx, request{method: http.MethodGet, path: "/engines"}, &engines)
+	return engines, err
+}
+
+func (c *Client) GetEnginesByIds(ctx context.Context, ids []string) ([]models.Engine, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "GetEnginesByIds-Client")
+	defer span.End()
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var engines []models.Engine
+	err := c.do(ctx, request{method: http.MethodGet, path: "/engines", query: url.Values{"ids": {strings.Join(ids, ",")}}}, &engines)
+	return engines, err
+}
+
+func (c *Client) CreateEngine(ctx context.Context, engineReq *models.EngineRequest) (models.Engine, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "CreateEngine-Client")
+	defer span.End()
+
+	var engine models.Engine
+	err := c.do(ctx, request{method: http.MethodPost, path: "/engines", body: engineReq, idempotent: true, idempotencyKey: newIdempotencyKey()}, &engine)
+	return engine, err
+}
+
+func (c *Client) UpdateEngine(ctx context.Context, id string, engineReq *models.EngineRequest) (models.Engine, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "UpdateEngine-Client")
+	defer span.End()
+
+	var engine models.Engine
+	err := c.do(ctx, request{method: http.MethodPut, path: "/engines/" + url.PathEscape(id), body: engineReq}, &engine)
+	return engine, err
+}
+
+func (c *Client) DeleteEngine(ctx context.Context, id string) (models.Engine, error) {
+	tracer := otel.Tracer("CarmClient")
+	ctx, span := tracer.Start(ctx, "DeleteEngine-Client")
+	defer span.End()
+
+	var engine models.Engine
+	err := c.do(ctx, request{method: http.MethodDelete, path: "/engines/" + url.PathEscape(id)}, &engine)
+	return engine, err
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/google/uuid"
+)
+
+// csvColumns are the columns "cars import" expects in a CSV file, in any
+// order; location_id may be left out.
+var csvColumns = []string{"name", "year", "brand", "fuel_type", "price", "engine_id", "location_id"}
+
+func (a *app) runCars(args []string) error {
+	name, rest, err := subcommand("cars", args, "list", "get", "create", "import")
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "list":
+		return a.listCars(rest)
+	case "get":
+		return a.getCar(rest)
+	case "create":
+		return a.createCar(rest)
+	default:
+		return a.importCars(rest)
+	}
+}
+
+func (a *app) listCars(args []string) error {
+	flags := flag.NewFlagSet("cars list", flag.ContinueOnError)
+	brand := flags.String("brand", "", "only cars of this brand")
+	minMileage := flags.Int64("min-mileage", -1, "minimum current mileage")
+	maxMileage := flags.Int64("max-mileage", -1, "maximum current mileage")
+	location := flags.String("location", "", "only cars at this location or its lots")
+	engine := flags.String("engine", "", "only cars with this engine")
+	limit := flags.Int("limit", 0, "maximum number of cars, 0 for all")
+	offset := flags.Int("offset", 0, "number of cars to skip")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	filter := models.CarFilter{Brand: *brand, IsEngine: true, Limit: *limit, Offset: *offset}
+	if *minMileage >= 0 {
+		filter.MinMileage = minMileage
+	}
+	if *maxMileage >= 0 {
+		filter.MaxMileage = maxMileage
+	}
+	if *location != "" {
+		id, err := uuid.Parse(*location)
+		if err != nil {
+			return fmt.Errorf("invalid location id %q", *location)
+		}
+		filter.LocationID = &id
+	}
+	if *engine != "" {
+		id, err := uuid.Parse(*engine)
+		if err != nil {
+			return fmt.Errorf("invalid engine id %q", *engine)
+		}
+		filter.EngineID = &id
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	cars, err := a.cars.GetCars(a.ctx, filter)
+	if err != nil {
+		return err
+	}
+	if cars == nil {
+		cars = []models.Car{}
+	}
+	return a.printCars(cars)
+}
+
+func (a *app) getCar(args []string) error {
+	flags := flag.NewFlagSet("cars get", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 1); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(flags.Output(), "Usage: carm cars get <id>")
+		return errUsage
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	car, err := a.cars.GetCarById(a.ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if car.ID == uuid.Nil {
+		return errors.New("car not found")
+	}
+	return a.out.print(car, carHeader, [][]string{carRow(car)})
+}
+
+func (a *app) createCar(args []string) error {
+	flags := flag.NewFlagSet("cars create", flag.ContinueOnError)
+	var carReq models.CarRequest
+	flags.StringVar(&carReq.Name, "name", "", "name of the car")
+	flags.StringVar(&carReq.Year, "year", "", "model year")
+	flags.StringVar(&carReq.Brand, "brand", "", "brand")
+	flags.StringVar(&carReq.FuelType, "fuel", "", "fuel type: Gasoline, Diesel, Electric or Hybrid")
+	flags.Float64Var(&carReq.Price, "price", 0, "price")
+	engine := flags.String("engine", "", "id of the engine")
+	location := flags.String("location", "", "id of the location to place the car at")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	if err := setCarIds(&carReq, *engine, *location); err != nil {
+		return err
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := a.fillEngines([]*models.CarRequest{&carReq}); err != nil {
+		return err
+	}
+	car, err := a.cars.CreateCar(a.ctx, &carReq)
+	if err != nil {
+		return err
+	}
+	return a.out.print(car, carHeader, [][]string{carRow(car)})
+}
+
+// importCars creates the cars of a JSON file, holding an array of car
+// requests as accepted by POST /cars, or of a CSV file with csvColumns.
+// The import runs as one batch and, unless -partial is given, creates
+// either all cars or none.
+func (a *app) importCars(args []string) error {
+	flags := flag.NewFlagSet("cars import", flag.ContinueOnError)
+	format := flags.String("format", "", "file format: json or csv; guessed from the file extension when empty")
+	partial := flags.Bool("partial", false, "keep the valid cars when some fail")
+	if err := parseFlags(flags, args, 1); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(flags.Output(), "Usage: carm cars import [-format json|csv] [-partial] <file>")
+		return errUsage
+	}
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var carReqs []*models.CarRequest
+	switch *format {
+	case "json":
+		err = json.NewDecoder(file).Decode(&carReqs)
+	case "csv":
+		carReqs, err = readCarsCSV(file)
+	default:
+		return fmt.Errorf("unknown import format %q, use json or csv", *format)
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if len(carReqs) == 0 {
+		return fmt.Errorf("%s has no cars", path)
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := a.fillEngines(carReqs); err != nil {
+		return err
+	}
+	atomic := !*partial
+	batchReq := models.CarBatchRequest{Atomic: &atomic}
+	for _, carReq := range carReqs {
+		batchReq.Operations = append(batchReq.Operations, models.CarBatchOperation{Op: models.CarBatchCreate, Car: carReq})
+	}
+	res, err := a.cars.RunCarBatch(a.ctx, &batchReq)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	rows := make([][]string, 0, len(res.Results))
+	for _, result := range res.Results {
+		id := ""
+		if result.Car != nil {
+			id = result.Car.ID.String()
+		}
+		if result.Status == models.CarBatchFailed {
+			failed++
+		}
+		rows = append(rows, []string{strconv.Itoa(result.Index + 1), result.Status, id, result.Error})
+	}
+	if err := a.out.print(res, []string{"ROW", "STATUS", "ID", "ERROR"}, rows); err != nil {
+		return err
+	}
+	if !res.Committed {
+		return fmt.Errorf("%d of %d cars failed, nothing was imported", failed, len(carReqs))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d cars failed", failed, len(carReqs))
+	}
+	a.out.message("Imported %d cars", len(carReqs))
+	return nil
+}
+
+func readCarsCSV(r io.Reader) ([]*models.CarRequest, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range csvColumns {
+		if _, ok := columns[name]; !ok && name != "location_id" {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+	value := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var carReqs []*models.CarRequest
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return carReqs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		carReq := models.CarRequest{
+			Name:     value(record, "name"),
+			Year:     value(record, "year"),
+			Brand:    value(record, "brand"),
+			FuelType: value(record, "fuel_type"),
+		}
+		if price := value(record, "price"); price != "" {
+			carReq.Price, err = strconv.ParseFloat(price, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: price must be a number", line)
+			}
+		}
+		if err := setCarIds(&carReq, value(record, "engine_id"), value(record, "location_id")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		carReqs = append(carReqs, &carReq)
+	}
+}
+
+func setCarIds(carReq *models.CarRequest, engine string, location string) error {
+	if engine != "" {
+		id, err := uuid.Parse(engine)
+		if err != nil {
+			return fmt.Errorf("invalid engine id %q", engine)
+		}
+		carReq.Engine.ID = id
+	}
+	if location != "" {
+		id, err := uuid.Parse(location)
+		if err != nil {
+			return fmt.Errorf("invalid location id %q", location)
+		}
+		carReq.LocationID = &id
+	}
+	return nil
+}
+
+// fillEngines completes the engines of the requests from their ids, as
+// car validation checks the engine details.
+func (a *app) fillEngines(carReqs []*models.CarRequest) error {
+	var ids []string
+	for _, carReq := range carReqs {
+		if carReq.Engine.ID != uuid.Nil {
+			ids = append(ids, carReq.Engine.ID.String())
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	engines, err := a.engines.GetEnginesByIds(a.ctx, ids)
+	if err != nil {
+		return err
+	}
+	byId := make(map[uuid.UUID]models.Engine, len(engines))
+	for _, engine := range engines {
+		byId[engine.ID] = engine
+	}
+	for _, carReq := range carReqs {
+		if carReq.Engine.ID == uuid.Nil {
+			continue
+		}
+		engine, ok := byId[carReq.Engine.ID]
+		if !ok {
+			return fmt.Errorf("engine %s not found", carReq.Engine.ID)
+		}
+		carReq.Engine = engine
+	}
+	return nil
+}
+
+var carHeader = []string{"ID", "NAME", "YEAR", "BRAND", "FUEL", "PRICE", "MILEAGE", "ENGINE", "LOCATION"}
+
+func carRow(car models.Car) []string {
+	engine := ""
+	if car.Engine.ID != uuid.Nil {
+		engine = car.Engine.ID.String()
+	}
+	location := ""
+	if car.LocationID != nil {
+		location = car.LocationID.String()
+	}
+	return []string{
+		car.ID.String(), car.Name, car.Year, car.Brand, car.FuelType,
+		strconv.FormatFloat(car.Price, 'f', 2, 64), strconv.FormatInt(car.Mileage, 10), engine, location,
+	}
+}
+
+func (a *app) printCars(cars []models.Car) error {
+	rows := make([][]string, 0, len(cars))
+	for _, car := range cars {
+		rows = append(rows, carRow(car))
+	}
+	return a.out.print(cars, carHeader, rows)
+}
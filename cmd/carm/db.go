@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+)
+
+// sampleEngines and sampleCars are the demo data "carm seed" adds; each
+// car refers to its engine by index.
+var sampleEngines = []models.EngineRequest{
+	{Displacement: 2000, NoOfCylinders: 4, CarRange: 600},
+	{Displacement: 1600, NoOfCylinders: 4, CarRange: 550},
+	{Displacement: 3000, NoOfCylinders: 6, CarRange: 700},
+	{Displacement: 1800, NoOfCylinders: 4, CarRange: 500},
+}
+
+var sampleCars = []struct {
+	engine int
+	car    models.CarRequest
+}{
+	{0, models.CarRequest{Name: "Honda Civic", Year: "2023", Brand: "Honda", FuelType: "Gasoline", Price: 25000}},
+	{1, models.CarRequest{Name: "Toyota Corolla", Year: "2022", Brand: "Toyota", FuelType: "Gasoline", Price: 22000}},
+	{2, models.CarRequest{Name: "Ford Mustang", Year: "2024", Brand: "Ford", FuelType: "Gasoline", Price: 40000}},
+	{3, models.CarRequest{Name: "BMW 3 Series", Year: "2023", Brand: "BMW", FuelType: "Gasoline", Price: 35000}},
+}
+
+func (a *app) migrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if err := store.Migrate(a.ctx, a.db); err != nil {
+		return err
+	}
+	a.out.message("Schema is up to date")
+	return nil
+}
+
+// seed adds the sample data through the services, so the usual events are
+// published for it. Tenants that already have engines are left alone.
+func (a *app) seed(args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	existing, err := a.engines.GetEngines(a.ctx)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		a.out.message("Tenant already has engines, not seeding")
+		return nil
+	}
+
+	engines := make([]models.Engine, 0, len(sampleEngines))
+	for _, engineReq := range sampleEngines {
+		engine, err := a.engines.CreateEngine(a.ctx, &engineReq)
+		if err != nil {
+			return err
+		}
+		engines = append(engines, engine)
+	}
+	cars := make([]models.Car, 0, len(sampleCars))
+	for _, sample := range sampleCars {
+		carReq := sample.car
+		carReq.Engine = engines[sample.engine]
+		car, err := a.cars.CreateCar(a.ctx, &carReq)
+		if err != nil {
+			return err
+		}
+		cars = append(cars, car)
+	}
+	return a.printCars(cars)
+}
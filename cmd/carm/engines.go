@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+	"time"
+
+	"github.com/Akmyrat17/carm/models"
+)
+
+func (a *app) runEngines(args []string) error {
+	_, rest, err := subcommand("engines", args, "list")
+	if err != nil {
+		return err
+	}
+	flags := flag.NewFlagSet("engines list", flag.ContinueOnError)
+	if err := parseFlags(flags, rest, 0); err != nil {
+		return err
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	engines, err := a.engines.GetEngines(a.ctx)
+	if err != nil {
+		return err
+	}
+	if engines == nil {
+		engines = []models.Engine{}
+	}
+	rows := make([][]string, 0, len(engines))
+	for _, engine := range engines {
+		rows = append(rows, []string{
+			engine.ID.String(),
+			strconv.FormatInt(engine.Displacement, 10),
+			strconv.FormatInt(engine.NoOfCylinders, 10),
+			strconv.FormatInt(engine.CarRange, 10),
+			engine.CreatedAt.Format(time.DateTime),
+		})
+	}
+	return a.out.print(engines, []string{"ID", "DISPLACEMENT", "CYLINDERS", "RANGE", "CREATED"}, rows)
+}
@@ -0,0 +1,181 @@
+// Command carm administers the carm database through the service layer,
+// for the fixes that would otherwise need psql. It reads the database and
+// blob storage settings from the same file and environment as the server.
+//
+//	carm [-config file] [-tenant id] [-o table|json] <command> [arguments]
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Akmyrat17/carm/blob"
+	"github.com/Akmyrat17/carm/config"
+	"github.com/Akmyrat17/carm/driver"
+	attachmentService "github.com/Akmyrat17/carm/service/attachment"
+	carService "github.com/Akmyrat17/carm/service/car"
+	engineService "github.com/Akmyrat17/carm/service/engine"
+	userService "github.com/Akmyrat17/carm/service/user"
+	attachmentStore "github.com/Akmyrat17/carm/store/attachment"
+	carStore "github.com/Akmyrat17/carm/store/car"
+	engineStore "github.com/Akmyrat17/carm/store/engine"
+	userStore "github.com/Akmyrat17/carm/store/user"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
+)
+
+const usage = `Usage: carm [-config file] [-tenant id] [-o table|json] <command> [arguments]
+
+Commands:
+  migrate                      create or upgrade the database schema
+  seed                         add sample engines and cars to the tenant
+  cars list [flags]            list cars
+  cars get <id>                show a car
+  cars create [flags]          create a car
+  cars import [flags] <file>   create cars from a JSON or CSV file
+  engines list                 list engines
+  users list                   list users
+  users create [flags]         create a user
+
+Run "carm <command> [subcommand] -h" for the flags of a command.
+
+Global flags:
+`
+
+// errUsage is returned for bad invocations; the usage has been printed.
+var errUsage = errors.New("invalid usage")
+
+type app struct {
+	ctx     context.Context
+	config  string
+	db      *sql.DB
+	out     *printer
+	cars    *carService.CarService
+	engines *engineService.EngineService
+	users   *userService.UserService
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "carm:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("carm", flag.ContinueOnError)
+	configFile := flags.String("config", "", "file with the server settings (default .env, or $"+config.FileEnv+")")
+	tenantFlag := flags.String("tenant", tenant.Default.String(), "id of the tenant to act for")
+	format := flags.String("o", formatTable, "output format: table or json")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+	out, err := newPrinter(os.Stdout, *format)
+	if err != nil {
+		return err
+	}
+	tenantId, err := uuid.Parse(*tenantFlag)
+	if err != nil {
+		return fmt.Errorf("invalid tenant id %q", *tenantFlag)
+	}
+
+	a := &app{ctx: tenant.NewContext(context.Background(), tenantId), config: *configFile, out: out}
+	defer a.close()
+	command, rest := flags.Arg(0), flags.Args()[1:]
+	switch command {
+	case "migrate":
+		return a.migrate(rest)
+	case "seed":
+		return a.seed(rest)
+	case "cars":
+		return a.runCars(rest)
+	case "engines":
+		return a.runEngines(rest)
+	case "users":
+		return a.runUsers(rest)
+	}
+	fmt.Fprintf(os.Stderr, "carm: unknown command %q\n", command)
+	flags.Usage()
+	return errUsage
+}
+
+// connect opens the database and sets up the services. Commands call it
+// once their arguments are parsed, so that -h works without a database.
+func (a *app) connect() error {
+	var args []string
+	if a.config != "" {
+		args = []string{"-config", a.config}
+	}
+	// Only the settings used here need to be valid, so the configuration
+	// is not validated as a whole.
+	cfg, err := config.Load(args)
+	if err != nil {
+		return fmt.Errorf("loading configuration: %w", err)
+	}
+	db, err := driver.Open(cfg.Database())
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+	a.db = db
+	blobStorage, err := blob.New(cfg.Blob())
+	if err != nil {
+		return fmt.Errorf("setting up blob storage: %w", err)
+	}
+	attachments := attachmentService.NewAttachmentService(attachmentStore.New(db), blobStorage, cfg.AttachmentMaxSize)
+	a.cars = carService.NewCarService(carStore.New(db), attachments)
+	a.engines = engineService.NewEngineService(engineStore.New(db))
+	a.users = userService.NewUserService(userStore.New(db))
+	return nil
+}
+
+func (a *app) close() {
+	if a.db != nil {
+		a.db.Close()
+	}
+}
+
+// subcommand splits args into the subcommand and its arguments.
+func subcommand(command string, args []string, subcommands ...string) (string, []string, error) {
+	if len(args) > 0 {
+		for _, name := range subcommands {
+			if args[0] == name {
+				return name, args[1:], nil
+			}
+		}
+		fmt.Fprintf(os.Stderr, "carm: unknown subcommand %q of %s\n", args[0], command)
+	}
+	fmt.Fprintf(os.Stderr, "Usage: carm %s <%s>\n", command, strings.Join(subcommands, "|"))
+	return "", nil, errUsage
+}
+
+// parseFlags parses the flags of a subcommand, which takes at most
+// maxArgs positional arguments.
+func parseFlags(flags *flag.FlagSet, args []string, maxArgs int) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() > maxArgs {
+		fmt.Fprintf(flags.Output(), "carm %s: unexpected arguments %v\n", flags.Name(), flags.Args()[maxArgs:])
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// printer writes command results either as an aligned table for people or
+// as the JSON the HTTP API would return, for scripts.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	if format != formatTable && format != formatJSON {
+		return nil, fmt.Errorf("unknown output format %q, use table or json", format)
+	}
+	return &printer{w: w, format: format}, nil
+}
+
+// print writes value as JSON, or header and rows as a table.
+func (p *printer) print(value interface{}, header []string, rows [][]string) error {
+	if p.format == formatJSON {
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// message writes a note that only makes sense to people; JSON output
+// stays parseable.
+func (p *printer) message(format string, args ...interface{}) {
+	if p.format == formatJSON {
+		return
+	}
+	fmt.Fprintf(p.w, format+"\n", args...)
+}
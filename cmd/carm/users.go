@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Akmyrat17/carm/models"
+)
+
+func (a *app) runUsers(args []string) error {
+	name, rest, err := subcommand("users", args, "list", "create")
+	if err != nil {
+		return err
+	}
+	if name == "list" {
+		return a.listUsers(rest)
+	}
+	return a.createUser(rest)
+}
+
+func (a *app) listUsers(args []string) error {
+	flags := flag.NewFlagSet("users list", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	users, err := a.users.GetUsers(a.ctx)
+	if err != nil {
+		return err
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+	rows := make([][]string, 0, len(users))
+	for _, user := range users {
+		rows = append(rows, userRow(user))
+	}
+	return a.out.print(users, userHeader, rows)
+}
+
+// createUser reads the password from the first line of stdin when
+// -password is not given, to keep it out of the shell history.
+func (a *app) createUser(args []string) error {
+	flags := flag.NewFlagSet("users create", flag.ContinueOnError)
+	var userReq models.UserRequest
+	flags.StringVar(&userReq.Username, "username", "", "name to sign in with")
+	flags.StringVar(&userReq.Password, "password", "", "password; read from stdin when empty")
+	flags.StringVar(&userReq.Role, "role", models.UserRoleMember, "role: platform-admin, admin or member")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	if userReq.Password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return errors.New("no password given on stdin")
+		}
+		userReq.Password = strings.TrimRight(line, "\r\n")
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	user, err := a.users.CreateUser(a.ctx, &userReq)
+	if err != nil {
+		return fmt.Errorf("creating user: %w", err)
+	}
+	return a.out.print(user, userHeader, [][]string{userRow(user)})
+}
+
+var userHeader = []string{"ID", "USERNAME", "ROLE", "CREATED"}
+
+func userRow(user models.User) []string {
+	return []string{user.ID.String(), user.Username, user.Role, user.CreatedAt.Format(time.DateTime)}
+}
@@ -0,0 +1,447 @@
+// Package config holds the settings of the carm server. They are read, in
+// increasing order of precedence, from the defaults, a .env style file,
+// the environment and command line flags:
+//
+//	DB_HOST=db carm-server -port 9090 -config /etc/carm.env
+//
+// Every setting has an environment variable; its flag is the same name in
+// lower case with dashes, so DB_HOST is also -db-host.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Akmyrat17/carm/blob"
+	"github.com/Akmyrat17/carm/cache"
+	"github.com/Akmyrat17/carm/driver"
+	"github.com/Akmyrat17/carm/health"
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/middleware"
+	"github.com/Akmyrat17/carm/outbox"
+	"github.com/Akmyrat17/carm/ratelimit"
+	attachmentService "github.com/Akmyrat17/carm/service/attachment"
+	carService "github.com/Akmyrat17/carm/service/car"
+	serviceRecordService "github.com/Akmyrat17/carm/service/servicerecord"
+	"github.com/Akmyrat17/carm/telemetry"
+	"github.com/joho/godotenv"
+)
+
+const (
+	// DefaultFile is read when it exists and no other file is named.
+	DefaultFile = ".env"
+	// FileEnv names a configuration file that must exist.
+	FileEnv = "CARM_CONFIG"
+
+	// MinJWTSecretLength keeps tokens from being signed with a guessable
+	// key.
+	MinJWTSecretLength = 16
+
+	redacted = "********"
+)
+
+// Config is the server configuration. The env tag names the variable of a
+// field, secret fields are redacted when printed.
+type Config struct {
+	Port     int `env:"PORT" usage:"HTTP port"`
+	GRPCPort int `env:"GRPC_PORT" usage:"gRPC port"`
+
+	HTTPReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" usage:"time allowed to read request headers"`
+	HTTPReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT" usage:"time allowed to read a whole request"`
+	HTTPWriteTimeout      time.Duration `env:"HTTP_WRITE_TIMEOUT" usage:"time allowed to write a response, except event streams"`
+	HTTPIdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT" usage:"how long idle keep-alive connections stay open"`
+	ShutdownTimeout       time.Duration `env:"SHUTDOWN_TIMEOUT" usage:"time allowed for a graceful shutdown"`
+	HealthCheckTimeout    time.Duration `env:"HEALTH_CHECK_TIMEOUT" usage:"time allowed for each readiness check"`
+
+	DBHost     string `env:"DB_HOST" usage:"Postgres host"`
+	DBPort     int    `env:"DB_PORT" usage:"Postgres port"`
+	DBUser     string `env:"DB_USER" usage:"Postgres user"`
+	DBPassword string `env:"DB_PASSWORD" secret:"true" usage:"Postgres password"`
+	DBName     string `env:"DB_NAME" usage:"Postgres database"`
+	DBSSLMode  string `env:"DB_SSLMODE" usage:"Postgres sslmode"`
+
+	DBMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" usage:"most open database connections, 0 for no limit"`
+	DBMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" usage:"most idle database connections kept open"`
+	DBConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" usage:"age after which a database connection is replaced, 0 for never"`
+	DBConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" usage:"idle time after which a database connection is closed, 0 for never"`
+	DBConnectTimeout  time.Duration `env:"DB_CONNECT_TIMEOUT" usage:"how long the server retries reaching the database on start"`
+
+	JWTSecret string `env:"JWT_SECRET" secret:"true" usage:"key that signs login tokens"`
+
+	TracingExporter    string  `env:"TRACING_EXPORTER" usage:"where traces go: otlp-http, otlp-grpc, stdout or none"`
+	TracingEndpoint    string  `env:"TRACING_ENDPOINT" usage:"OTLP collector host:port, empty for localhost:4318 (HTTP) or localhost:4317 (gRPC)"`
+	TracingSampleRatio float64 `env:"TRACING_SAMPLE_RATIO" usage:"share of new traces recorded, from 0 to 1"`
+
+	LogLevel  string `env:"LOG_LEVEL" usage:"lowest level logged: debug, info, warn or error"`
+	LogFormat string `env:"LOG_FORMAT" usage:"log output: text or json"`
+
+	BlobBackend  string `env:"BLOB_BACKEND" usage:"attachment storage: local or s3"`
+	BlobLocalDir string `env:"BLOB_LOCAL_DIR" usage:"directory of the local attachment storage"`
+	S3Endpoint   string `env:"S3_ENDPOINT" usage:"S3 endpoint URL"`
+	S3Region     string `env:"S3_REGION" usage:"S3 region"`
+	S3Bucket     string `env:"S3_BUCKET" usage:"S3 bucket"`
+	S3AccessKey  string `env:"S3_ACCESS_KEY" usage:"S3 access key"`
+	S3SecretKey  string `env:"S3_SECRET_KEY" secret:"true" usage:"S3 secret key"`
+
+	AttachmentMaxSize int64         `env:"ATTACHMENT_MAX_SIZE" usage:"largest attachment upload in bytes"`
+	CarBatchMaxSize   int           `env:"CAR_BATCH_MAX_SIZE" usage:"most operations in a car batch"`
+	ServiceIntervals  string        `env:"SERVICE_INTERVALS" usage:"service intervals per fuel type, which stands in for the engine type, as fuel_type:kilometers:months,..."`
+	IdempotencyTTL    time.Duration `env:"IDEMPOTENCY_TTL" usage:"how long responses are replayed for an Idempotency-Key"`
+
+	OutboxSinks   []string `env:"OUTBOX_SINKS" usage:"external outbox sinks: log, http, nats, kafka"`
+	OutboxHTTPURL string   `env:"OUTBOX_HTTP_URL" usage:"URL the http sink posts to"`
+	NATSURL       string   `env:"NATS_URL" usage:"NATS server of the nats sink"`
+	NATSSubject   string   `env:"NATS_SUBJECT" usage:"subject of the nats sink"`
+	KafkaRESTURL  string   `env:"KAFKA_REST_URL" usage:"Kafka REST proxy of the kafka sink"`
+	KafkaTopic    string   `env:"KAFKA_TOPIC" usage:"topic of the kafka sink"`
+
+	RateLimitBackend   string        `env:"RATE_LIMIT_BACKEND" usage:"where rate limit buckets are kept: memory, postgres or redis"`
+	RedisURL           string        `env:"REDIS_URL" secret:"true" usage:"redis:// URL of the redis rate limit and cache backends"`
+	RateLimit          string        `env:"RATE_LIMIT" usage:"requests per client as limit/period[:burst], or none"`
+	RateLimitRoutes    []string      `env:"RATE_LIMIT_ROUTES" usage:"own limits of routes as METHOD /path=limit/period[:burst],..."`
+	LoginMaxFailures   int           `env:"LOGIN_MAX_FAILURES" usage:"failed sign-ins of an account per LOGIN_FAILURE_WINDOW before it is locked, 0 for no lockout"`
+	LoginFailureWindow time.Duration `env:"LOGIN_FAILURE_WINDOW" usage:"window of LOGIN_MAX_FAILURES"`
+
+	CacheBackend string        `env:"CACHE_BACKEND" usage:"cache of car and engine lookups: memory, redis or none"`
+	CacheSize    int           `env:"CACHE_SIZE" usage:"most entries of the memory cache"`
+	CacheTTL     time.Duration `env:"CACHE_TTL" usage:"how long lookups are cached"`
+
+	// PrintConfig asks the server to print the configuration and exit.
+	PrintConfig bool `env:"-"`
+}
+
+func Defaults() Config {
+	return Config{
+		Port:                  8080,
+		GRPCPort:              50051,
+		HTTPReadHeaderTimeout: 10 * time.Second,
+		HTTPReadTimeout:       time.Minute,
+		HTTPWriteTimeout:      time.Minute,
+		HTTPIdleTimeout:       2 * time.Minute,
+		ShutdownTimeout:       30 * time.Second,
+		HealthCheckTimeout:    health.DefaultTimeout,
+		DBHost:                "localhost",
+		DBPort:                5432,
+		DBUser:                "postgres",
+		DBName:                "postgres",
+		DBSSLMode:             "disable",
+		DBMaxOpenConns:        25,
+		DBMaxIdleConns:        10,
+		DBConnMaxLifetime:     30 * time.Minute,
+		DBConnMaxIdleTime:     5 * time.Minute,
+		DBConnectTimeout:      time.Minute,
+		TracingExporter:       telemetry.ExporterOTLPHTTP,
+		TracingSampleRatio:    1,
+		LogLevel:              "info",
+		LogFormat:             logging.FormatText,
+		BlobBackend:           "local",
+		BlobLocalDir:          "data/attachments",
+		AttachmentMaxSize:     attachmentService.DefaultMaxSize,
+		CarBatchMaxSize:       carService.DefaultBatchMaxSize,
+		IdempotencyTTL:        middleware.DefaultIdempotencyTTL,
+		NATSURL:               "nats://localhost:4222",
+		NATSSubject:           "carm.events",
+		KafkaTopic:            "carm.events",
+		RateLimitBackend:      ratelimit.BackendMemory,
+		RateLimit:             "600/1m",
+		RateLimitRoutes:       []string{"POST /login=10/1m", "POST /cars/batch=30/1m"},
+		LoginMaxFailures:      5,
+		LoginFailureWindow:    15 * time.Minute,
+		CacheBackend:          cache.BackendMemory,
+		CacheSize:             cache.DefaultSize,
+		CacheTTL:              cache.DefaultTTL,
+	}
+}
+
+// Load reads the configuration for the command line args. It does not
+// validate it, so that -print-config can show a broken configuration.
+func Load(args []string) (Config, error) {
+	cfg := Defaults()
+
+	flags := flag.NewFlagSet("carm", flag.ContinueOnError)
+	file := flags.String("config", "", "file with KEY=value settings (default .env, or $"+FileEnv+")")
+	flags.BoolVar(&cfg.PrintConfig, "print-config", false, "print the configuration with secrets redacted and exit")
+	flagEnv := make(map[string]string)
+	for _, field := range fields(&cfg) {
+		flagEnv[flagName(field.env)] = field.env
+		flags.String(flagName(field.env), "", field.usage+" ($"+field.env+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		return cfg, err
+	}
+	if flags.NArg() > 0 {
+		return cfg, fmt.Errorf("unexpected arguments %q", flags.Args())
+	}
+
+	path, required := *file, true
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path == "" {
+		path, required = DefaultFile, false
+	}
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if required || !errors.Is(err, os.ErrNotExist) {
+			return cfg, fmt.Errorf("reading %s: %w", path, err)
+		}
+		values = map[string]string{}
+	}
+	for _, env := range flagEnv {
+		if value, ok := os.LookupEnv(env); ok {
+			values[env] = value
+		}
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if env, ok := flagEnv[f.Name]; ok {
+			values[env] = f.Value.String()
+		}
+	})
+
+	var errs []error
+	for _, field := range fields(&cfg) {
+		value := strings.TrimSpace(values[field.env])
+		if value == "" {
+			continue
+		}
+		if err := field.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field.env, err))
+		}
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(validPort(c.Port), "PORT: %d is not a valid port", c.Port)
+	check(validPort(c.GRPCPort), "GRPC_PORT: %d is not a valid port", c.GRPCPort)
+	check(c.Port != c.GRPCPort, "GRPC_PORT: must differ from PORT")
+	check(c.HTTPReadHeaderTimeout > 0, "HTTP_READ_HEADER_TIMEOUT: must be positive")
+	check(c.HTTPReadTimeout > 0, "HTTP_READ_TIMEOUT: must be positive")
+	check(c.HTTPWriteTimeout > 0, "HTTP_WRITE_TIMEOUT: must be positive")
+	check(c.HTTPIdleTimeout > 0, "HTTP_IDLE_TIMEOUT: must be positive")
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT: must be positive")
+	check(c.HealthCheckTimeout > 0, "HEALTH_CHECK_TIMEOUT: must be positive")
+	check(c.DBHost != "", "DB_HOST: is required")
+	check(validPort(c.DBPort), "DB_PORT: %d is not a valid port", c.DBPort)
+	check(c.DBUser != "", "DB_USER: is required")
+	check(c.DBName != "", "DB_NAME: is required")
+	check(c.DBMaxOpenConns >= 0, "DB_MAX_OPEN_CONNS: must not be negative")
+	check(c.DBMaxIdleConns >= 0, "DB_MAX_IDLE_CONNS: must not be negative")
+	check(c.DBMaxOpenConns == 0 || c.DBMaxIdleConns <= c.DBMaxOpenConns, "DB_MAX_IDLE_CONNS: must not exceed DB_MAX_OPEN_CONNS")
+	check(c.DBConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME: must not be negative")
+	check(c.DBConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME: must not be negative")
+	check(c.DBConnectTimeout > 0, "DB_CONNECT_TIMEOUT: must be positive")
+	check(c.JWTSecret != "", "JWT_SECRET: is required")
+	check(c.JWTSecret == "" || len(c.JWTSecret) >= MinJWTSecretLength, "JWT_SECRET: must be at least %d characters", MinJWTSecretLength)
+	check(telemetry.ValidExporter(c.TracingExporter), "TRACING_EXPORTER: must be otlp-http, otlp-grpc, stdout or none, not %q", c.TracingExporter)
+	check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1, "TRACING_SAMPLE_RATIO: must be between 0 and 1")
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: %w", err))
+	}
+	check(c.LogFormat == logging.FormatText || c.LogFormat == logging.FormatJSON, "LOG_FORMAT: must be text or json, not %q", c.LogFormat)
+	check(c.BlobBackend == "local" || c.BlobBackend == "s3", "BLOB_BACKEND: must be local or s3, not %q", c.BlobBackend)
+	check(c.BlobBackend != "s3" || c.S3Bucket != "", "S3_BUCKET: is required for the s3 backend")
+	check(c.AttachmentMaxSize > 0, "ATTACHMENT_MAX_SIZE: must be positive")
+	check(c.CarBatchMaxSize > 0, "CAR_BATCH_MAX_SIZE: must be positive")
+	check(c.IdempotencyTTL > 0, "IDEMPOTENCY_TTL: must be positive")
+	if _, err := serviceRecordService.ParseIntervals(c.ServiceIntervals); err != nil {
+		errs = append(errs, fmt.Errorf("SERVICE_INTERVALS: %w", err))
+	}
+	backends := []string{ratelimit.BackendMemory, ratelimit.BackendPostgres, ratelimit.BackendRedis}
+	check(slices.Contains(backends, c.RateLimitBackend), "RATE_LIMIT_BACKEND: must be memory, postgres or redis, not %q", c.RateLimitBackend)
+	check(c.RateLimitBackend != ratelimit.BackendRedis || c.RedisURL != "", "REDIS_URL: is required for the redis rate limit backend")
+	if _, err := ratelimit.ParsePolicy(c.RateLimit); err != nil {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT: %w", err))
+	}
+	if _, err := middleware.ParseRateLimitRoutes(c.RateLimitRoutes); err != nil {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_ROUTES: %w", err))
+	}
+	check(c.LoginMaxFailures >= 0, "LOGIN_MAX_FAILURES: must not be negative")
+	check(c.LoginFailureWindow > 0, "LOGIN_FAILURE_WINDOW: must be positive")
+	cacheBackends := []string{cache.BackendMemory, cache.BackendRedis, cache.BackendNone}
+	check(slices.Contains(cacheBackends, c.CacheBackend), "CACHE_BACKEND: must be memory, redis or none, not %q", c.CacheBackend)
+	check(c.CacheBackend != cache.BackendRedis || c.RedisURL != "", "REDIS_URL: is required for the redis cache")
+	check(c.CacheSize > 0, "CACHE_SIZE: must be positive")
+	check(c.CacheTTL > 0, "CACHE_TTL: must be positive")
+	return errors.Join(errs...)
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// Print writes the configuration as KEY=value lines that Load can read
+// back, except for the redacted secrets.
+func (c Config) Print(w io.Writer) error {
+	for _, field := range fields(&c) {
+		value := field.String()
+		if field.secret && value != "" {
+			value = redacted
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", field.env, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c Config) Database() driver.Config {
+	return driver.Config{
+		Host:     c.DBHost,
+		Port:     c.DBPort,
+		User:     c.DBUser,
+		Password: c.DBPassword,
+		Name:     c.DBName,
+		SSLMode:  c.DBSSLMode,
+
+		MaxOpenConns:    c.DBMaxOpenConns,
+		MaxIdleConns:    c.DBMaxIdleConns,
+		ConnMaxLifetime: c.DBConnMaxLifetime,
+		ConnMaxIdleTime: c.DBConnMaxIdleTime,
+	}
+}
+
+func (c Config) Telemetry() telemetry.Config {
+	return telemetry.Config{
+		Exporter:    c.TracingExporter,
+		Endpoint:    c.TracingEndpoint,
+		SampleRatio: c.TracingSampleRatio,
+	}
+}
+
+func (c Config) RateLimitStore() ratelimit.Config {
+	return ratelimit.Config{
+		Backend:  c.RateLimitBackend,
+		RedisURL: c.RedisURL,
+	}
+}
+
+func (c Config) Cache() cache.Config {
+	return cache.Config{
+		Backend:  c.CacheBackend,
+		Size:     c.CacheSize,
+		RedisURL: c.RedisURL,
+	}
+}
+
+func (c Config) Blob() blob.Config {
+	return blob.Config{
+		Backend:  c.BlobBackend,
+		LocalDir: c.BlobLocalDir,
+		S3: blob.S3Config{
+			Endpoint:  c.S3Endpoint,
+			Region:    c.S3Region,
+			Bucket:    c.S3Bucket,
+			AccessKey: c.S3AccessKey,
+			SecretKey: c.S3SecretKey,
+		},
+	}
+}
+
+func (c Config) Sinks() outbox.SinkConfig {
+	return outbox.SinkConfig{
+		Sinks:       c.OutboxSinks,
+		HTTPURL:     c.OutboxHTTPURL,
+		NATSURL:     c.NATSURL,
+		NATSSubject: c.NATSSubject,
+		KafkaURL:    c.KafkaRESTURL,
+		KafkaTopic:  c.KafkaTopic,
+	}
+}
+
+// field is a setting of a Config.
+type field struct {
+	env    string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+func fields(c *Config) []field {
+	value := reflect.ValueOf(c).Elem()
+	var result []field
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		env := structField.Tag.Get("env")
+		if env == "" || env == "-" {
+			continue
+		}
+		result = append(result, field{
+			env:    env,
+			usage:  structField.Tag.Get("usage"),
+			secret: structField.Tag.Get("secret") == "true",
+			value:  value.Field(i),
+		})
+	}
+	return result
+}
+
+func (f field) set(raw string) error {
+	switch f.value.Interface().(type) {
+	case string:
+		f.value.SetString(raw)
+	case int, int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || f.value.OverflowInt(n) {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		f.value.SetInt(n)
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		f.value.SetBool(b)
+	case float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		f.value.SetFloat(n)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		f.value.SetInt(int64(d))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported type %s", f.value.Type())
+	}
+	return nil
+}
+
+func (f field) String() string {
+	switch v := f.value.Interface().(type) {
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
@@ -1,49 +1,130 @@
 package driver
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"net"
+	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
-var db *sql.DB
+const (
+	minConnectBackoff = 500 * time.Millisecond
+	maxConnectBackoff = 10 * time.Second
+)
 
-func InitDB() {
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-	)
+// Config names the Postgres database to connect to and sizes the pool.
+type Config struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
 
-	fmt.Println("Connecting to database...")
-	time.Sleep(5 * time.Second)
+	// MaxOpenConns and MaxIdleConns bound the pool; 0 leaves the open
+	// connections unlimited and keeps no idle ones.
+	MaxOpenConns int
+	MaxIdleConns int
+	// ConnMaxLifetime and ConnMaxIdleTime retire connections; 0 keeps them
+	// forever.
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
 
-	var err error
-	db, err = sql.Open("postgres", connStr) // 🔥 FIXED: no shadowing
-	if err != nil {
-		log.Fatal("Error connecting to database: ", err)
+// DSN returns the connection URL for lib/pq.
+func (c Config) DSN() string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.Name,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
 	}
+	return dsn.String()
+}
 
-	err = db.Ping()
+// Connect opens the pool and pings the database until it answers, backing
+// off between attempts, so that the server can start before Postgres has.
+// It gives up once ctx is done.
+func Connect(ctx context.Context, cfg Config) (*sql.DB, error) {
+	db, err := open(cfg)
 	if err != nil {
-		log.Fatal("Error pinging database: ", err)
+		return nil, err
 	}
 
-	fmt.Println("Connected to database")
+	backoff := minConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return db, nil
+		}
+		logging.FromContext(ctx).Warn("Database not reachable, retrying", "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
 }
 
-func GetDB() *sql.DB {
-	return db
+// Open connects to the database and pings it once, for tools that should
+// fail right away rather than wait for the database.
+func Open(cfg Config) (*sql.DB, error) {
+	db, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
 }
 
-func CloseDB() {
-	if err := db.Close(); err != nil {
-		log.Fatal("Error closing database: ", err)
+// open traces every query as a span of the calling request that carries
+// the SQL statement, but not its arguments, and records the query
+// latency through the OpenTelemetry meter provider. Pings, such as those
+// of Connect and the readiness check, are not traced.
+func open(cfg Config) (*sql.DB, error) {
+	db, err := otelsql.Open("postgres", cfg.DSN(),
+		otelsql.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBNameKey.String(cfg.Name),
+		),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
+	if err != nil {
+		return nil, err
 	}
+	configurePool(db, cfg)
+	return db, nil
+}
+
+func configurePool(db *sql.DB, cfg Config) {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
+
+// RegisterMetrics exports the pool statistics of db, such as open, in-use
+// and idle connections and the time spent waiting for one, as go_sql_*
+// metrics labelled with the database name.
+func RegisterMetrics(db *sql.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
 }
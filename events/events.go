@@ -0,0 +1,217 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
+)
+
+const (
+	EntityCar    = "car"
+	EntityEngine = "engine"
+
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+	// ActionRepriced follows the updated event of a car whose price changed.
+	ActionRepriced = "repriced"
+)
+
+// Types lists every event type the services publish.
+var Types = []string{
+	"car.created", "car.updated", "car.repriced", "car.deleted",
+	"engine.created", "engine.updated", "engine.deleted",
+}
+
+// ErrClosed is returned by Subscribe once the broker is closed.
+var ErrClosed = errors.New("event broker closed")
+
+// DefaultHistory is how many past events a broker keeps for clients that
+// resume with Last-Event-ID.
+const DefaultHistory = 1000
+
+// subscriberBuffer is how far a subscriber may fall behind before it is
+// dropped. A dropped client reconnects and catches up from the history.
+const subscriberBuffer = 64
+
+type Event struct {
+	ID int64 `json:"id"`
+	// Key identifies the change itself; an event relayed twice keeps its
+	// key, so consumers can drop the duplicate.
+	Key      string      `json:"key,omitempty"`
+	Entity   string      `json:"entity"`
+	Action   string      `json:"action"`
+	Brand    string      `json:"brand,omitempty"`
+	Data     interface{} `json:"data"`
+	Time     time.Time   `json:"time"`
+	TenantID uuid.UUID   `json:"-"`
+}
+
+// Type names the event for the SSE event field, e.g. "car.updated".
+func (e Event) Type() string {
+	return e.Entity + "." + e.Action
+}
+
+// Filter selects the events a subscriber receives. Zero values match all.
+type Filter struct {
+	Entity string
+	Brand  string
+}
+
+func (f Filter) matches(e Event) bool {
+	if f.Entity != "" && f.Entity != e.Entity {
+		return false
+	}
+	if f.Brand != "" && f.Brand != e.Brand {
+		return false
+	}
+	return true
+}
+
+// Publisher is what the services emit change events through.
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+type Subscription struct {
+	C <-chan Event
+
+	c        chan Event
+	tenantId uuid.UUID
+	filter   Filter
+	broker   *Broker
+}
+
+// Close stops delivery to the subscription and closes C.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+// Broker fans events out to subscribers of the same tenant and keeps a
+// bounded history of past events. It lives in process memory, so every
+// instance of the API only streams the changes it made itself.
+type Broker struct {
+	mu          sync.Mutex
+	lastId      int64
+	history     []Event
+	keys        map[string]struct{}
+	size        int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+func NewBroker(history int) *Broker {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &Broker{
+		// Ids start at the boot time in microseconds, so they keep growing
+		// across restarts and a stale Last-Event-ID replays the whole
+		// history instead of nothing.
+		lastId:      time.Now().UnixMicro(),
+		size:        history,
+		keys:        map[string]struct{}{},
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Publish assigns the event an id and delivers it to the subscribers of
+// the tenant in ctx. Events whose key is still in the history are
+// duplicates and dropped. Subscribers that cannot keep up are dropped too.
+func (b *Broker) Publish(ctx context.Context, event Event) {
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, seen := b.keys[event.Key]; seen && event.Key != "" {
+		return
+	}
+	b.lastId++
+	event.ID = b.lastId
+	event.TenantID = tenantId
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if len(b.history) == b.size {
+		delete(b.keys, b.history[0].Key)
+		b.history = append(b.history[:0], b.history[1:]...)
+	}
+	b.history = append(b.history, event)
+	if event.Key != "" {
+		b.keys[event.Key] = struct{}{}
+	}
+
+	for sub := range b.subscribers {
+		if sub.tenantId != tenantId || !sub.filter.matches(event) {
+			continue
+		}
+		select {
+		case sub.c <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the tenant in ctx. Events after
+// lastEventId that are still in the history are returned for replay; pass
+// 0 to only receive new events.
+func (b *Broker) Subscribe(ctx context.Context, filter Filter, lastEventId int64) (*Subscription, []Event, error) {
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, nil, ErrClosed
+	}
+
+	var replay []Event
+	if lastEventId > 0 {
+		for _, event := range b.history {
+			if event.ID > lastEventId && event.TenantID == tenantId && filter.matches(event) {
+				replay = append(replay, event)
+			}
+		}
+	}
+	c := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: c, c: c, tenantId: tenantId, filter: filter, broker: b}
+	b.subscribers[sub] = struct{}{}
+	return sub, replay, nil
+}
+
+// Close ends every subscription and refuses new ones, so that open
+// streams finish when the server shuts down.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+// Closed reports whether Close has been called.
+func (b *Broker) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.c)
+}
@@ -0,0 +1,127 @@
+package graphqlserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/middleware"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"go.opentelemetry.io/otel"
+)
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type Handler struct {
+	schema  graphql.Schema
+	cars    service.CarServiceInterface
+	engines service.EngineServiceInterface
+}
+
+func NewHandler(schema graphql.Schema, cars service.CarServiceInterface, engines service.EngineServiceInterface) *Handler {
+	return &Handler{schema: schema, cars: cars, engines: engines}
+}
+
+// ServeHTTP executes a query sent either as a JSON POST body or through
+// the query, operationName and variables parameters of a GET request.
+// API keys need the read scope for queries and the write scope for
+// mutations. Queries above the depth and complexity limits are refused
+// before they run.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("GraphQLHandler")
+	ctx, span := tracer.Start(r.Context(), "GraphQL-Handler")
+	defer span.End()
+
+	var req request
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				http.Error(w, "variables must be a JSON object", http.StatusBadRequest)
+				return
+			}
+		}
+	} else {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+			return
+		}
+	}
+	if req.Query == "" {
+		http.Error(w, "query is required", http.StatusBadRequest)
+		return
+	}
+
+	// Documents that do not parse are left for graphql.Do to report.
+	document, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		document = &ast.Document{}
+	}
+	scope := models.APIKeyScopeRead
+	if isMutation(document, req.OperationName) {
+		if r.Method == http.MethodGet {
+			// A GET must not change anything, so mutations need a POST.
+			http.Error(w, "mutations must be sent with POST", http.StatusMethodNotAllowed)
+			return
+		}
+		scope = models.APIKeyScopeWrite
+	}
+	if !middleware.HasScope(ctx, scope) {
+		http.Error(w, "API key lacks the "+scope+" scope", http.StatusForbidden)
+		return
+	}
+	if err := checkLimits(document, req.OperationName, req.Variables); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        withCarLoader(withLoader(ctx, newEngineLoader(h.engines)), newCarLoader(h.cars)),
+	})
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error marshalling graphql result", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(body)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
+
+// isMutation reports whether the operation that would run is a mutation.
+func isMutation(document *ast.Document, operationName string) bool {
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName != "" && (operation.Name == nil || operation.Name.Value != operationName) {
+			continue
+		}
+		if operation.Operation == ast.OperationTypeMutation {
+			return true
+		}
+	}
+	return false
+}
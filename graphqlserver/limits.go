@@ -0,0 +1,126 @@
+package graphqlserver
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	// maxDepth bounds how deeply selections nest. It leaves room for the
+	// introspection query while stopping queries that cycle through
+	// engine.cars and car.engine.
+	maxDepth = 15
+	// maxComplexity bounds the number of fields a query may resolve. A
+	// field counts once, plus its selections times its limit argument,
+	// so that a list of a hundred cars costs a hundred times its fields.
+	maxComplexity = 10000
+)
+
+// checkLimits rejects operations that nest deeper than maxDepth or cost
+// more than maxComplexity before they run. Like isMutation, it checks
+// every operation when operationName is empty. Unknown and cyclic
+// fragments are skipped for graphql.Do to report.
+func checkLimits(document *ast.Document, operationName string, variables map[string]interface{}) error {
+	checker := limitChecker{
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+		visiting:  map[string]bool{},
+	}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			checker.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName != "" && (operation.Name == nil || operation.Name.Value != operationName) {
+			continue
+		}
+		cost, err := checker.cost(operation.SelectionSet, 1)
+		if err != nil {
+			return err
+		}
+		if cost > maxComplexity {
+			return fmt.Errorf("query is too complex: it may resolve more than %d fields", maxComplexity)
+		}
+	}
+	return nil
+}
+
+type limitChecker struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool
+}
+
+// cost returns the complexity of the selections at depth.
+func (c *limitChecker) cost(set *ast.SelectionSet, depth int) (int, error) {
+	if set == nil {
+		return 0, nil
+	}
+	total := 0
+	for _, selection := range set.Selections {
+		var cost int
+		var err error
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if depth > maxDepth {
+				return 0, fmt.Errorf("query is nested deeper than %d levels", maxDepth)
+			}
+			cost, err = c.cost(selection.SelectionSet, depth+1)
+			cost = min(1+cost*c.limit(selection), maxComplexity+1)
+		case *ast.InlineFragment:
+			cost, err = c.cost(selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := c.fragments[name]
+			if !ok || c.visiting[name] {
+				continue
+			}
+			c.visiting[name] = true
+			cost, err = c.cost(fragment.SelectionSet, depth)
+			delete(c.visiting, name)
+		}
+		if err != nil {
+			return 0, err
+		}
+		total += cost
+		if total > maxComplexity {
+			// Stop early; the caller reports the exceeded limit.
+			return maxComplexity + 1, nil
+		}
+	}
+	return total, nil
+}
+
+// limit returns the limit argument of a paged field, at most maxLimit as
+// larger ones fail anyway, defaultLimit when it is left out, and 1 for
+// fields without one.
+func (c *limitChecker) limit(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if limit, err := strconv.Atoi(value.Value); err == nil && limit > 0 {
+				return min(limit, maxLimit)
+			}
+		case *ast.Variable:
+			// JSON numbers decode as float64.
+			if limit, ok := c.variables[value.Name.Value].(float64); ok && limit > 0 {
+				return int(min(limit, maxLimit))
+			}
+		}
+		return defaultLimit
+	}
+	if field.Name.Value == "cars" {
+		return defaultLimit
+	}
+	return 1
+}
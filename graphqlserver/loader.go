@@ -0,0 +1,173 @@
+package graphqlserver
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
+)
+
+type loaderKey struct{}
+
+// engineLoader batches engine lookups of one request. Resolvers queue ids
+// through load and get back a thunk; the executor resolves thunks only
+// after the whole level of the query is walked, so the first thunk fetches
+// every queued id at once instead of one query per car.
+type engineLoader struct {
+	service service.EngineServiceInterface
+
+	mu      sync.Mutex
+	pending []string
+	queued  map[string]bool
+	engines map[string]models.Engine
+}
+
+func newEngineLoader(service service.EngineServiceInterface) *engineLoader {
+	return &engineLoader{
+		service: service,
+		queued:  map[string]bool{},
+		engines: map[string]models.Engine{},
+	}
+}
+
+func withLoader(ctx context.Context, loader *engineLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+func loaderFromContext(ctx context.Context) *engineLoader {
+	loader, _ := ctx.Value(loaderKey{}).(*engineLoader)
+	return loader
+}
+
+func (l *engineLoader) load(ctx context.Context, id string) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.queued[id] {
+		l.queued[id] = true
+		l.pending = append(l.pending, id)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		if err := l.flush(ctx); err != nil {
+			return nil, err
+		}
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		engine, ok := l.engines[id]
+		if !ok {
+			return nil, nil
+		}
+		return engine, nil
+	}
+}
+
+func (l *engineLoader) flush(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.pending) == 0 {
+		return nil
+	}
+	ids := l.pending
+	l.pending = nil
+	engines, err := l.service.GetEnginesByIds(ctx, ids)
+	if err != nil {
+		// Let a later thunk retry the ids instead of caching the failure.
+		for _, id := range ids {
+			delete(l.queued, id)
+		}
+		return err
+	}
+	for _, engine := range engines {
+		l.engines[engine.ID.String()] = engine
+	}
+	return nil
+}
+
+type carLoaderKey struct{}
+
+// carPageKey identifies the page of cars an engine.cars field asks for.
+// Aliases can ask for different pages in one query, so every page is
+// batched on its own.
+type carPageKey struct {
+	limit  int
+	offset int
+}
+
+// carLoader batches the engine.cars lookups of one request the way
+// engineLoader batches engines: the first thunk of a page fetches the cars
+// of every queued engine in one query.
+type carLoader struct {
+	service service.CarServiceInterface
+
+	mu      sync.Mutex
+	pending map[carPageKey][]string
+	queued  map[carPageKey]map[string]bool
+	cars    map[carPageKey]map[string][]models.Car
+}
+
+func newCarLoader(service service.CarServiceInterface) *carLoader {
+	return &carLoader{
+		service: service,
+		pending: map[carPageKey][]string{},
+		queued:  map[carPageKey]map[string]bool{},
+		cars:    map[carPageKey]map[string][]models.Car{},
+	}
+}
+
+func withCarLoader(ctx context.Context, loader *carLoader) context.Context {
+	return context.WithValue(ctx, carLoaderKey{}, loader)
+}
+
+func carLoaderFromContext(ctx context.Context) *carLoader {
+	loader, _ := ctx.Value(carLoaderKey{}).(*carLoader)
+	return loader
+}
+
+// load queues engineId and returns a thunk with the cars of the page.
+func (l *carLoader) load(ctx context.Context, engineId string, page carPageKey) func() ([]models.Car, error) {
+	l.mu.Lock()
+	if l.queued[page] == nil {
+		l.queued[page] = map[string]bool{}
+	}
+	if !l.queued[page][engineId] {
+		l.queued[page][engineId] = true
+		l.pending[page] = append(l.pending[page], engineId)
+	}
+	l.mu.Unlock()
+
+	return func() ([]models.Car, error) {
+		if err := l.flush(ctx, page); err != nil {
+			return nil, err
+		}
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.cars[page][engineId], nil
+	}
+}
+
+func (l *carLoader) flush(ctx context.Context, page carPageKey) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.pending[page]) == 0 {
+		return nil
+	}
+	engineIds := l.pending[page]
+	delete(l.pending, page)
+	cars, err := l.service.GetCarsByEngineIds(ctx, engineIds, page.limit, page.offset)
+	if err != nil {
+		// Let a later thunk retry the engines instead of caching the failure.
+		for _, engineId := range engineIds {
+			delete(l.queued[page], engineId)
+		}
+		return err
+	}
+	if l.cars[page] == nil {
+		l.cars[page] = map[string][]models.Car{}
+	}
+	for _, car := range cars {
+		engineId := car.Engine.ID.String()
+		l.cars[page][engineId] = append(l.cars[page][engineId], car)
+	}
+	return nil
+}
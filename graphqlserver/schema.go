@@ -0,0 +1,370 @@
+package graphqlserver
+
+import (
+	"errors"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type resolvers struct {
+	cars    service.CarServiceInterface
+	engines service.EngineServiceInterface
+}
+
+// NewSchema builds the GraphQL schema of cars and engines on top of the
+// same services the REST and gRPC APIs use.
+func NewSchema(cars service.CarServiceInterface, engines service.EngineServiceInterface) (graphql.Schema, error) {
+	r := &resolvers{cars: cars, engines: engines}
+
+	engineType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Engine",
+		Fields: graphql.Fields{
+			"id":            engineField(graphql.NewNonNull(graphql.ID), func(e models.Engine) interface{} { return e.ID.String() }),
+			"displacement":  engineField(graphql.NewNonNull(graphql.Int), func(e models.Engine) interface{} { return e.Displacement }),
+			"noOfCylinders": engineField(graphql.NewNonNull(graphql.Int), func(e models.Engine) interface{} { return e.NoOfCylinders }),
+			"carRange":      engineField(graphql.NewNonNull(graphql.Int), func(e models.Engine) interface{} { return e.CarRange }),
+			"createdAt":     engineField(graphql.DateTime, func(e models.Engine) interface{} { return e.CreatedAt }),
+			"updatedAt":     engineField(graphql.DateTime, func(e models.Engine) interface{} { return e.UpdatedAt }),
+		},
+	})
+
+	carType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Car",
+		Fields: graphql.Fields{
+			"id":       carField(graphql.NewNonNull(graphql.ID), func(c models.Car) interface{} { return c.ID.String() }),
+			"name":     carField(graphql.NewNonNull(graphql.String), func(c models.Car) interface{} { return c.Name }),
+			"year":     carField(graphql.NewNonNull(graphql.String), func(c models.Car) interface{} { return c.Year }),
+			"fuelType": carField(graphql.NewNonNull(graphql.String), func(c models.Car) interface{} { return c.FuelType }),
+			"brand":    carField(graphql.NewNonNull(graphql.String), func(c models.Car) interface{} { return c.Brand }),
+			"price":    carField(graphql.NewNonNull(graphql.Float), func(c models.Car) interface{} { return c.Price }),
+			"mileage":  carField(graphql.NewNonNull(graphql.Int), func(c models.Car) interface{} { return c.Mileage }),
+			"locationId": carField(graphql.ID, func(c models.Car) interface{} {
+				if c.LocationID == nil {
+					return nil
+				}
+				return c.LocationID.String()
+			}),
+			"createdAt": carField(graphql.DateTime, func(c models.Car) interface{} { return c.CreatedAt }),
+			"updatedAt": carField(graphql.DateTime, func(c models.Car) interface{} { return c.UpdatedAt }),
+			"engine": &graphql.Field{
+				Type:    engineType,
+				Resolve: r.carEngine,
+			},
+		},
+	})
+
+	carPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "CarPage",
+		Fields: graphql.Fields{
+			"items":       &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(carType)))},
+			"limit":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"offset":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		},
+	})
+
+	pageArgs := graphql.FieldConfigArgument{
+		"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultLimit},
+		"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+	}
+	engineType.AddFieldConfig("cars", &graphql.Field{
+		Type:    graphql.NewNonNull(carPageType),
+		Args:    pageArgs,
+		Resolve: r.engineCars,
+	})
+
+	carsArgs := graphql.FieldConfigArgument{
+		"brand":      &graphql.ArgumentConfig{Type: graphql.String},
+		"minMileage": &graphql.ArgumentConfig{Type: graphql.Int},
+		"maxMileage": &graphql.ArgumentConfig{Type: graphql.Int},
+		"locationId": &graphql.ArgumentConfig{Type: graphql.ID},
+		"engineId":   &graphql.ArgumentConfig{Type: graphql.ID},
+	}
+	for name, arg := range pageArgs {
+		carsArgs[name] = arg
+	}
+	idArgs := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"car":    &graphql.Field{Type: carType, Args: idArgs, Resolve: r.car},
+			"cars":   &graphql.Field{Type: graphql.NewNonNull(carPageType), Args: carsArgs, Resolve: r.carList},
+			"engine": &graphql.Field{Type: engineType, Args: idArgs, Resolve: r.engine},
+		},
+	})
+
+	carInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CarInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"year":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"fuelType":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"brand":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"price":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Float)},
+			"engineId":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.ID)},
+			"locationId": &graphql.InputObjectFieldConfig{Type: graphql.ID},
+		},
+	})
+	engineInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "EngineInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"displacement":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"noOfCylinders": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"carRange":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+	inputArgs := func(input *graphql.InputObject, withId bool) graphql.FieldConfigArgument {
+		args := graphql.FieldConfigArgument{
+			"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(input)},
+		}
+		if withId {
+			args["id"] = idArgs["id"]
+		}
+		return args
+	}
+
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createCar":    &graphql.Field{Type: carType, Args: inputArgs(carInput, false), Resolve: r.createCar},
+			"updateCar":    &graphql.Field{Type: carType, Args: inputArgs(carInput, true), Resolve: r.updateCar},
+			"deleteCar":    &graphql.Field{Type: carType, Args: idArgs, Resolve: r.deleteCar},
+			"createEngine": &graphql.Field{Type: engineType, Args: inputArgs(engineInput, false), Resolve: r.createEngine},
+			"updateEngine": &graphql.Field{Type: engineType, Args: inputArgs(engineInput, true), Resolve: r.updateEngine},
+			"deleteEngine": &graphql.Field{Type: engineType, Args: idArgs, Resolve: r.deleteEngine},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+	})
+}
+
+func carField(t graphql.Output, get func(models.Car) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(models.Car)), nil
+		},
+	}
+}
+
+func engineField(t graphql.Output, get func(models.Engine) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(models.Engine)), nil
+		},
+	}
+}
+
+func (r *resolvers) carEngine(p graphql.ResolveParams) (interface{}, error) {
+	car := p.Source.(models.Car)
+	if car.Engine.ID == uuid.Nil {
+		return nil, nil
+	}
+	loader := loaderFromContext(p.Context)
+	if loader == nil {
+		return r.engines.GetEngineById(p.Context, car.Engine.ID.String())
+	}
+	return loader.load(p.Context, car.Engine.ID.String()), nil
+}
+
+// engineCars batches the cars of all engines in the result through the
+// request's car loader, so that listing engines with their cars takes one
+// query per page rather than one per engine.
+func (r *resolvers) engineCars(p graphql.ResolveParams) (interface{}, error) {
+	engine := p.Source.(models.Engine)
+	loader := carLoaderFromContext(p.Context)
+	if loader == nil {
+		return r.carPage(p, models.CarFilter{EngineID: &engine.ID})
+	}
+	limit, offset, err := pageArgs(p)
+	if err != nil {
+		return nil, err
+	}
+	cars := loader.load(p.Context, engine.ID.String(), carPageKey{limit: limit + 1, offset: offset})
+	return func() (interface{}, error) {
+		page, err := cars()
+		if err != nil {
+			return nil, err
+		}
+		return carPageResult(page, limit, offset), nil
+	}, nil
+}
+
+func (r *resolvers) car(p graphql.ResolveParams) (interface{}, error) {
+	car, err := r.cars.GetCarById(p.Context, p.Args["id"].(string))
+	if err != nil {
+		return nil, err
+	}
+	if car.ID == uuid.Nil {
+		return nil, nil
+	}
+	return car, nil
+}
+
+func (r *resolvers) carList(p graphql.ResolveParams) (interface{}, error) {
+	var filter models.CarFilter
+	if brand, ok := p.Args["brand"].(string); ok {
+		filter.Brand = brand
+	}
+	if minMileage, ok := p.Args["minMileage"].(int); ok {
+		value := int64(minMileage)
+		filter.MinMileage = &value
+	}
+	if maxMileage, ok := p.Args["maxMileage"].(int); ok {
+		value := int64(maxMileage)
+		filter.MaxMileage = &value
+	}
+	for name, target := range map[string]**uuid.UUID{"locationId": &filter.LocationID, "engineId": &filter.EngineID} {
+		value, ok := p.Args[name].(string)
+		if !ok {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, errors.New(name + " must be a valid id")
+		}
+		*target = &id
+	}
+	return r.carPage(p, filter)
+}
+
+// carPage fetches one row past the requested page to tell whether another
+// page follows.
+func (r *resolvers) carPage(p graphql.ResolveParams, filter models.CarFilter) (interface{}, error) {
+	limit, offset, err := pageArgs(p)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = limit + 1
+	filter.Offset = offset
+	cars, err := r.cars.GetCars(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+	return carPageResult(cars, limit, offset), nil
+}
+
+func pageArgs(p graphql.ResolveParams) (int, int, error) {
+	limit, _ := p.Args["limit"].(int)
+	offset, _ := p.Args["offset"].(int)
+	if limit <= 0 || limit > maxLimit {
+		return 0, 0, errors.New("limit must be between 1 and 100")
+	}
+	if offset < 0 {
+		return 0, 0, errors.New("offset cannot be negative")
+	}
+	return limit, offset, nil
+}
+
+// carPageResult builds a CarPage from cars fetched with one row past limit.
+func carPageResult(cars []models.Car, limit int, offset int) map[string]interface{} {
+	hasNextPage := len(cars) > limit
+	if hasNextPage {
+		cars = cars[:limit]
+	}
+	if cars == nil {
+		cars = []models.Car{}
+	}
+	return map[string]interface{}{
+		"items":       cars,
+		"limit":       limit,
+		"offset":      offset,
+		"hasNextPage": hasNextPage,
+	}
+}
+
+func (r *resolvers) engine(p graphql.ResolveParams) (interface{}, error) {
+	return r.engines.GetEngineById(p.Context, p.Args["id"].(string))
+}
+
+func (r *resolvers) createCar(p graphql.ResolveParams) (interface{}, error) {
+	carReq, err := r.carRequest(p)
+	if err != nil {
+		return nil, err
+	}
+	car, err := r.cars.CreateCar(p.Context, carReq)
+	if err != nil {
+		return nil, err
+	}
+	car.Engine = carReq.Engine
+	return car, nil
+}
+
+func (r *resolvers) updateCar(p graphql.ResolveParams) (interface{}, error) {
+	carReq, err := r.carRequest(p)
+	if err != nil {
+		return nil, err
+	}
+	car, err := r.cars.UpdateCar(p.Context, p.Args["id"].(string), carReq)
+	if err != nil {
+		return nil, err
+	}
+	car.Engine = carReq.Engine
+	return car, nil
+}
+
+func (r *resolvers) deleteCar(p graphql.ResolveParams) (interface{}, error) {
+	return r.cars.DeleteCar(p.Context, p.Args["id"].(string))
+}
+
+// carRequest maps CarInput onto a CarRequest. The input only names the
+// engine, so its details are looked up for validation.
+func (r *resolvers) carRequest(p graphql.ResolveParams) (*models.CarRequest, error) {
+	input := p.Args["input"].(map[string]interface{})
+	engine, err := r.engines.GetEngineById(p.Context, input["engineId"].(string))
+	if err != nil {
+		return nil, err
+	}
+	carReq := &models.CarRequest{
+		Name:     input["name"].(string),
+		Year:     input["year"].(string),
+		FuelType: input["fuelType"].(string),
+		Brand:    input["brand"].(string),
+		Price:    input["price"].(float64),
+		Engine:   engine,
+	}
+	if value, ok := input["locationId"].(string); ok {
+		locationId, err := uuid.Parse(value)
+		if err != nil {
+			return nil, errors.New("locationId must be a valid id")
+		}
+		carReq.LocationID = &locationId
+	}
+	return carReq, nil
+}
+
+func (r *resolvers) createEngine(p graphql.ResolveParams) (interface{}, error) {
+	return r.engines.CreateEngine(p.Context, engineRequest(p))
+}
+
+func (r *resolvers) updateEngine(p graphql.ResolveParams) (interface{}, error) {
+	return r.engines.UpdateEngine(p.Context, p.Args["id"].(string), engineRequest(p))
+}
+
+func (r *resolvers) deleteEngine(p graphql.ResolveParams) (interface{}, error) {
+	return r.engines.DeleteEngine(p.Context, p.Args["id"].(string))
+}
+
+func engineRequest(p graphql.ResolveParams) *models.EngineRequest {
+	input := p.Args["input"].(map[string]interface{})
+	return &models.EngineRequest{
+		Displacement:  int64(input["displacement"].(int)),
+		NoOfCylinders: int64(input["noOfCylinders"].(int)),
+		CarRange:      int64(input["carRange"].(int)),
+	}
+}
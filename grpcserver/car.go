@@ -0,0 +1,139 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/proto/carmpb"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type CarServer struct {
+	carmpb.UnimplementedCarServiceServer
+	service service.CarServiceInterface
+}
+
+func (s *CarServer) GetCar(ctx context.Context, req *carmpb.GetCarRequest) (*carmpb.Car, error) {
+	car, err := s.service.GetCarById(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if car.ID == uuid.Nil {
+		return nil, status.Error(codes.NotFound, "car not found in database")
+	}
+	return carToProto(car), nil
+}
+
+func (s *CarServer) ListCars(ctx context.Context, req *carmpb.ListCarsRequest) (*carmpb.ListCarsResponse, error) {
+	filter := models.CarFilter{
+		Brand:      req.GetBrand(),
+		IsEngine:   req.GetIsEngine(),
+		MinMileage: req.MinMileage,
+		MaxMileage: req.MaxMileage,
+	}
+	if req.LocationId != nil {
+		locationId, err := uuid.Parse(req.GetLocationId())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "location_id must be a valid id")
+		}
+		filter.LocationID = &locationId
+	}
+	cars, err := s.service.GetCars(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	res := &carmpb.ListCarsResponse{Cars: make([]*carmpb.Car, 0, len(cars))}
+	for _, car := range cars {
+		res.Cars = append(res.Cars, carToProto(car))
+	}
+	return res, nil
+}
+
+func (s *CarServer) CreateCar(ctx context.Context, req *carmpb.CreateCarRequest) (*carmpb.Car, error) {
+	carReq, err := carRequestFromProto(req.GetCar())
+	if err != nil {
+		return nil, err
+	}
+	car, err := s.service.CreateCar(ctx, carReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return carToProto(car), nil
+}
+
+func (s *CarServer) UpdateCar(ctx context.Context, req *carmpb.UpdateCarRequest) (*carmpb.Car, error) {
+	carReq, err := carRequestFromProto(req.GetCar())
+	if err != nil {
+		return nil, err
+	}
+	car, err := s.service.UpdateCar(ctx, req.GetId(), carReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return carToProto(car), nil
+}
+
+func (s *CarServer) DeleteCar(ctx context.Context, req *carmpb.DeleteCarRequest) (*carmpb.Car, error) {
+	car, err := s.service.DeleteCar(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return carToProto(car), nil
+}
+
+func carToProto(car models.Car) *carmpb.Car {
+	res := &carmpb.Car{
+		Id:        car.ID.String(),
+		Name:      car.Name,
+		Year:      car.Year,
+		FuelType:  car.FuelType,
+		Price:     car.Price,
+		Engine:    engineToProto(car.Engine),
+		Brand:     car.Brand,
+		Mileage:   car.Mileage,
+		CreatedAt: timestamppb.New(car.CreatedAt),
+		UpdatedAt: timestamppb.New(car.UpdatedAt),
+	}
+	if car.LocationID != nil {
+		locationId := car.LocationID.String()
+		res.LocationId = &locationId
+	}
+	return res
+}
+
+func carRequestFromProto(req *carmpb.CarRequest) (*models.CarRequest, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "car is required")
+	}
+	carReq := &models.CarRequest{
+		Name:     req.GetName(),
+		Year:     req.GetYear(),
+		FuelType: req.GetFuelType(),
+		Brand:    req.GetBrand(),
+		Price:    req.GetPrice(),
+	}
+	if engine := req.GetEngine(); engine != nil {
+		engineId, err := uuid.Parse(engine.GetId())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "engine id must be a valid id")
+		}
+		carReq.Engine = models.Engine{
+			ID:            engineId,
+			Displacement:  engine.GetDisplacement(),
+			NoOfCylinders: engine.GetNoOfCylinders(),
+			CarRange:      engine.GetCarRange(),
+		}
+	}
+	if req.LocationId != nil {
+		locationId, err := uuid.Parse(req.GetLocationId())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "location_id must be a valid id")
+		}
+		carReq.LocationID = &locationId
+	}
+	return carReq, nil
+}
@@ -0,0 +1,79 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/proto/carmpb"
+	"github.com/Akmyrat17/carm/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type EngineServer struct {
+	carmpb.UnimplementedEngineServiceServer
+	service service.EngineServiceInterface
+}
+
+func (s *EngineServer) GetEngine(ctx context.Context, req *carmpb.GetEngineRequest) (*carmpb.Engine, error) {
+	engine, err := s.service.GetEngineById(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return engineToProto(engine), nil
+}
+
+func (s *EngineServer) CreateEngine(ctx context.Context, req *carmpb.CreateEngineRequest) (*carmpb.Engine, error) {
+	engineReq, err := engineRequestFromProto(req.GetEngine())
+	if err != nil {
+		return nil, err
+	}
+	engine, err := s.service.CreateEngine(ctx, engineReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return engineToProto(engine), nil
+}
+
+func (s *EngineServer) UpdateEngine(ctx context.Context, req *carmpb.UpdateEngineRequest) (*carmpb.Engine, error) {
+	engineReq, err := engineRequestFromProto(req.GetEngine())
+	if err != nil {
+		return nil, err
+	}
+	engine, err := s.service.UpdateEngine(ctx, req.GetId(), engineReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return engineToProto(engine), nil
+}
+
+func (s *EngineServer) DeleteEngine(ctx context.Context, req *carmpb.DeleteEngineRequest) (*carmpb.Engine, error) {
+	engine, err := s.service.DeleteEngine(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return engineToProto(engine), nil
+}
+
+func engineToProto(engine models.Engine) *carmpb.Engine {
+	return &carmpb.Engine{
+		Id:            engine.ID.String(),
+		Displacement:  engine.Displacement,
+		NoOfCylinders: engine.NoOfCylinders,
+		CarRange:      engine.CarRange,
+		CreatedAt:     timestamppb.New(engine.CreatedAt),
+		UpdatedAt:     timestamppb.New(engine.UpdatedAt),
+	}
+}
+
+func engineRequestFromProto(req *carmpb.EngineRequest) (*models.EngineRequest, error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "engine is required")
+	}
+	return &models.EngineRequest{
+		Displacement:  req.GetDisplacement(),
+		NoOfCylinders: req.GetNoOfCylinders(),
+		CarRange:      req.GetCarRange(),
+	}, nil
+}
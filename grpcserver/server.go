@@ -0,0 +1,189 @@
+// Package grpcserver exposes the car and engine services over gRPC next to
+// the REST handlers.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Akmyrat17/carm/health"
+	"github.com/Akmyrat17/carm/middleware"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/proto/carmpb"
+	"github.com/Akmyrat17/carm/service"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// publicMethods skip authentication so probes and tooling work without a
+// token.
+var publicMethods = map[string]bool{
+	"/grpc.health.v1.Health/Check":                                   true,
+	"/grpc.health.v1.Health/Watch":                                   true,
+	"/grpc.health.v1.Health/List":                                    true,
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      true,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": true,
+}
+
+// healthInterval is how often the health service is refreshed from the
+// readiness checks.
+const healthInterval = 10 * time.Second
+
+// Server is the gRPC server together with the health service that reports
+// whether it can take calls.
+type Server struct {
+	*grpc.Server
+	health *grpchealth.Server
+}
+
+// New builds a gRPC server with the car and engine services, auth
+// interceptors, OpenTelemetry instrumentation, health checking and
+// reflection registered. Every service reports NOT_SERVING until
+// WatchHealth has run the readiness checks once.
+func New(carService service.CarServiceInterface, engineService service.EngineServiceInterface, auth *middleware.Auth) *Server {
+	interceptors := authInterceptors{auth: auth}
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(interceptors.unary),
+		grpc.ChainStreamInterceptor(interceptors.stream),
+	)
+	carmpb.RegisterCarServiceServer(server, &CarServer{service: carService})
+	carmpb.RegisterEngineServiceServer(server, &EngineServer{service: engineService})
+
+	s := &Server{Server: server, health: grpchealth.NewServer()}
+	s.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, s.health)
+	reflection.Register(server)
+	return s
+}
+
+// WatchHealth runs the readiness checks every healthInterval until ctx is
+// cancelled, and reports every service as SERVING while the required
+// checks pass and NOT_SERVING otherwise.
+func (s *Server) WatchHealth(ctx context.Context, checker *health.Checker) {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if checker.Run(ctx).Status != health.StatusOK {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		s.setServingStatus(status)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ShutdownHealth reports every service as NOT_SERVING for good, so that
+// clients stop sending calls before the server drains.
+func (s *Server) ShutdownHealth() {
+	s.health.Shutdown()
+}
+
+func (s *Server) setServingStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	s.health.SetServingStatus("", status)
+	s.health.SetServingStatus(carmpb.CarService_ServiceDesc.ServiceName, status)
+	s.health.SetServingStatus(carmpb.EngineService_ServiceDesc.ServiceName, status)
+}
+
+type authInterceptors struct {
+	auth *middleware.Auth
+}
+
+func (i authInterceptors) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	ctx, err := i.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (i authInterceptors) stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if publicMethods[info.FullMethod] {
+		return handler(srv, stream)
+	}
+	ctx, err := i.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticate applies the same checks as the HTTP auth middleware to the
+// "authorization", "x-api-key" and "x-tenant-id" metadata.
+func (i authInterceptors) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx, err := i.auth.Authenticate(ctx, firstValue(md, "authorization"), firstValue(md, "x-api-key"), firstValue(md, "x-tenant-id"))
+	if err != nil {
+		var forbidden *middleware.ForbiddenError
+		switch {
+		case errors.As(err, &forbidden):
+			return ctx, status.Error(codes.PermissionDenied, err.Error())
+		case middleware.Unauthenticated(err):
+			return ctx, status.Error(codes.Unauthenticated, err.Error())
+		default:
+			return ctx, status.Error(codes.Internal, "authentication failed")
+		}
+	}
+	if scope := methodScope(fullMethod); !middleware.HasScope(ctx, scope) {
+		return ctx, status.Error(codes.PermissionDenied, "API key lacks the "+scope+" scope")
+	}
+	return ctx, nil
+}
+
+// methodScope returns the API key scope of a method: read for the Get and
+// List methods, write for the others.
+func methodScope(fullMethod string) string {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	if strings.HasPrefix(name, "Get") || strings.HasPrefix(name, "List") {
+		return models.APIKeyScopeRead
+	}
+	return models.APIKeyScopeWrite
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// toStatus maps service errors onto gRPC status codes. The services report
+// most failures as plain errors, so the mapping mostly goes by message.
+func toStatus(err error) error {
+	message := err.Error()
+	switch {
+	case errors.Is(err, models.ErrEngineInUse):
+		return status.Error(codes.FailedPrecondition, message)
+	case strings.Contains(message, "not found"):
+		return status.Error(codes.NotFound, message)
+	case strings.Contains(message, "cannot be"), strings.Contains(message, "must be"),
+		strings.Contains(message, "invalid"):
+		return status.Error(codes.InvalidArgument, message)
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
@@ -0,0 +1,115 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/middleware"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+)
+
+type APIKeyHandler struct {
+	service service.APIKeyServiceInterface
+}
+
+func NewAPIKeyHandler(service service.APIKeyServiceInterface) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("APIKeyHandler")
+	ctx, span := tracer.Start(r.Context(), "GetAPIKeys-Handler")
+	defer span.End()
+
+	res, err := h.service.GetAPIKeys(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting api keys", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *APIKeyHandler) GetAPIKeyById(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("APIKeyHandler")
+	ctx, span := tracer.Start(r.Context(), "GetAPIKeyById-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	res, err := h.service.GetAPIKeyById(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting api key by id", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("APIKeyHandler")
+	ctx, span := tracer.Start(r.Context(), "CreateAPIKey-Handler")
+	defer span.End()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var keyReq models.APIKeyRequest
+	err = json.Unmarshal(body, &keyReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+	keyReq.CreatedBy = middleware.Username(ctx)
+
+	res, err := h.service.CreateAPIKey(ctx, &keyReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error creating api key", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusCreated, res)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("APIKeyHandler")
+	ctx, span := tracer.Start(r.Context(), "RevokeAPIKey-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	res, err := h.service.RevokeAPIKey(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error revoking api key", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, res interface{}) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error marshalling api key", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
@@ -0,0 +1,170 @@
+package attachment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+)
+
+type AttachmentHandler struct {
+	service service.AttachmentServiceInterface
+	maxSize int64
+}
+
+func NewAttachmentHandler(service service.AttachmentServiceInterface, maxSize int64) *AttachmentHandler {
+	return &AttachmentHandler{service: service, maxSize: maxSize}
+}
+
+func (h *AttachmentHandler) GetAttachments(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("AttachmentHandler")
+	ctx, span := tracer.Start(r.Context(), "GetAttachments-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+
+	res, err := h.service.GetAttachments(ctx, carId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting attachments", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+// UploadAttachment accepts a multipart form with a "file" part and a "kind"
+// field (photo, registration, inspection or other).
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("AttachmentHandler")
+	ctx, span := tracer.Start(r.Context(), "UploadAttachment-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+
+	// Leave room for the multipart framing and the other form fields.
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("file cannot be larger than %d bytes", h.maxSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error parsing multipart form", "error", err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, h.maxSize+1))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading uploaded file", "error", err)
+		return
+	}
+
+	upload := models.AttachmentUpload{
+		Kind:     r.FormValue("kind"),
+		FileName: header.Filename,
+		Content:  content,
+	}
+	res, err := h.service.UploadAttachment(ctx, carId, &upload)
+	if err != nil {
+		if errors.Is(err, models.ErrUnsupportedContentType) {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+		if errors.Is(err, models.ErrInvalidUpload) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error uploading attachment", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusCreated, res)
+}
+
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	h.download(w, r, false)
+}
+
+func (h *AttachmentHandler) DownloadThumbnail(w http.ResponseWriter, r *http.Request) {
+	h.download(w, r, true)
+}
+
+func (h *AttachmentHandler) download(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	tracer := otel.Tracer("AttachmentHandler")
+	ctx, span := tracer.Start(r.Context(), "DownloadAttachment-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+	attachmentId := vars["attachmentId"]
+
+	attachment, content, err := h.service.OpenAttachment(ctx, carId, attachmentId, thumbnail)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error opening attachment", "error", err)
+		return
+	}
+	defer content.Close()
+
+	if thumbnail {
+		w.Header().Set("Content-Type", "image/jpeg")
+	} else {
+		w.Header().Set("Content-Type", attachment.ContentType)
+		w.Header().Set("Content-Length", fmt.Sprint(attachment.Size))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", attachment.FileName))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
+
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("AttachmentHandler")
+	ctx, span := tracer.Start(r.Context(), "DeleteAttachment-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+	attachmentId := vars["attachmentId"]
+
+	res, err := h.service.DeleteAttachment(ctx, carId, attachmentId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error deleting attachment", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, res interface{}) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error marshalling attachment", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
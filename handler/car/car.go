@@ -2,22 +2,32 @@ package car
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 
+	"github.com/Akmyrat17/carm/logging"
 	"github.com/Akmyrat17/carm/models"
 	"github.com/Akmyrat17/carm/service"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"go.opentelemetry.io/otel"
 )
 
+// maxBatchOperationSize is how many bytes of the request body a batch may
+// spend on each operation it is allowed to have.
+const maxBatchOperationSize = 16 << 10
+
 type CarHandler struct {
-	service service.CarServiceInterface
+	service      service.CarServiceInterface
+	maxBatchSize int
 }
 
-func NewCarHandler(service service.CarServiceInterface) *CarHandler {
-	return &CarHandler{service: service}
+func NewCarHandler(service service.CarServiceInterface, maxBatchSize int) *CarHandler {
+	return &CarHandler{service: service, maxBatchSize: maxBatchSize}
 }
 
 func (h *CarHandler) GetCarByID(w http.ResponseWriter, r *http.Request) {
@@ -31,13 +41,13 @@ func (h *CarHandler) GetCarByID(w http.ResponseWriter, r *http.Request) {
 	res, err := h.service.GetCarById(ctx, id)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error getting car by id: ", err)
+		logging.FromContext(ctx).Error("Error getting car by id", "error", err)
 		return
 	}
 	body, err := json.Marshal(res)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling car: ", err)
+		logging.FromContext(ctx).Error("Error marshalling car", "error", err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -45,28 +55,33 @@ func (h *CarHandler) GetCarByID(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error writing response: ", err)
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
 		return
 	}
 }
 
-func (h *CarHandler) GetCarByBrand(w http.ResponseWriter, r *http.Request) {
+func (h *CarHandler) GetCars(w http.ResponseWriter, r *http.Request) {
 	// ctx := r.Context()
 	tracer := otel.Tracer("CarHandler")
-	ctx, span := tracer.Start(r.Context(), "GetCarByBrand-Handler")
+	ctx, span := tracer.Start(r.Context(), "GetCars-Handler")
 	defer span.End()
-	brand := r.URL.Query().Get("brand")
 
-	res, err := h.service.GetCarByBrand(ctx, brand, false)
+	filter, err := parseCarFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.service.GetCars(ctx, filter)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error getting car by brand: ", err)
+		logging.FromContext(ctx).Error("Error getting cars", "error", err)
 		return
 	}
 	body, err := json.Marshal(res)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling car: ", err)
+		logging.FromContext(ctx).Error("Error marshalling car", "error", err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -74,7 +89,48 @@ func (h *CarHandler) GetCarByBrand(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error writing response: ", err)
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
+
+// GetCarsByLocation lists the cars at a location and all of its lots, taking
+// the same filters as GetCars.
+func (h *CarHandler) GetCarsByLocation(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "GetCarsByLocation-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+
+	filter, err := parseCarFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	locationId, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid location id", http.StatusBadRequest)
+		return
+	}
+	filter.LocationID = &locationId
+
+	res, err := h.service.GetCars(ctx, filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting cars by location", "error", err)
+		return
+	}
+	body, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error marshalling car", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(body)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
 		return
 	}
 }
@@ -88,7 +144,7 @@ func (h *CarHandler) CreateCar(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error reading request body: ", err)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
 		return
 	}
 
@@ -96,20 +152,20 @@ func (h *CarHandler) CreateCar(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, &carReq)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		log.Println("Error unmarshalling request body: ", err)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
 		return
 	}
 
 	res, err := h.service.CreateCar(ctx, &carReq)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error creating car: ", err)
+		logging.FromContext(ctx).Error("Error creating car", "error", err)
 		return
 	}
 	body, err = json.Marshal(res)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling car: ", err)
+		logging.FromContext(ctx).Error("Error marshalling car", "error", err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -117,7 +173,7 @@ func (h *CarHandler) CreateCar(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error writing response: ", err)
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
 		return
 	}
 }
@@ -133,7 +189,7 @@ func (h *CarHandler) UpdateCar(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error reading request body: ", err)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
 		return
 	}
 
@@ -141,20 +197,20 @@ func (h *CarHandler) UpdateCar(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, &carReq)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		log.Println("Error unmarshalling request body: ", err)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
 		return
 	}
 
 	res, err := h.service.UpdateCar(ctx, id, &carReq)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error updating car: ", err)
+		logging.FromContext(ctx).Error("Error updating car", "error", err)
 		return
 	}
 	body, err = json.Marshal(res)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling car: ", err)
+		logging.FromContext(ctx).Error("Error marshalling car", "error", err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -162,7 +218,7 @@ func (h *CarHandler) UpdateCar(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error writing response: ", err)
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
 		return
 	}
 }
@@ -178,13 +234,13 @@ func (h *CarHandler) DeleteCar(w http.ResponseWriter, r *http.Request) {
 	res, err := h.service.DeleteCar(ctx, id)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error deleting car: ", err)
+		logging.FromContext(ctx).Error("Error deleting car", "error", err)
 		return
 	}
 	body, err := json.Marshal(res)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling car: ", err)
+		logging.FromContext(ctx).Error("Error marshalling car", "error", err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -192,7 +248,122 @@ func (h *CarHandler) DeleteCar(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error writing response: ", err)
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
+
+// RunCarBatch applies a list of create, update and delete operations in
+// one transaction. The response carries a result per operation; a rolled
+// back atomic batch is answered with 422.
+func (h *CarHandler) RunCarBatch(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("CarHandler")
+	ctx, span := tracer.Start(r.Context(), "RunCarBatch-Handler")
+	defer span.End()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(h.maxBatchSize)*maxBatchOperationSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var batchReq models.CarBatchRequest
+	err = json.Unmarshal(body, &batchReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+	if len(batchReq.Operations) == 0 {
+		http.Error(w, "operations cannot be empty", http.StatusBadRequest)
+		return
+	}
+	if len(batchReq.Operations) > h.maxBatchSize {
+		http.Error(w, fmt.Sprintf("a batch cannot have more than %d operations", h.maxBatchSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	res, err := h.service.RunCarBatch(ctx, &batchReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error running car batch", "error", err)
+		return
+	}
+	body, err = json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error marshalling car batch", "error", err)
+		return
+	}
+	status := http.StatusOK
+	if !res.Committed {
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
 		return
 	}
 }
+
+func parseCarFilter(query url.Values) (models.CarFilter, error) {
+	filter := models.CarFilter{Brand: query.Get("brand")}
+	if isEngine := query.Get("isEngine"); isEngine != "" {
+		value, err := strconv.ParseBool(isEngine)
+		if err != nil {
+			return filter, errors.New("isEngine must be a boolean")
+		}
+		filter.IsEngine = value
+	}
+	if minMileage := query.Get("min_mileage"); minMileage != "" {
+		value, err := strconv.ParseInt(minMileage, 10, 64)
+		if err != nil {
+			return filter, errors.New("min_mileage must be a number")
+		}
+		filter.MinMileage = &value
+	}
+	if locationId := query.Get("location_id"); locationId != "" {
+		value, err := uuid.Parse(locationId)
+		if err != nil {
+			return filter, errors.New("location_id must be a valid id")
+		}
+		filter.LocationID = &value
+	}
+	if maxMileage := query.Get("max_mileage"); maxMileage != "" {
+		value, err := strconv.ParseInt(maxMileage, 10, 64)
+		if err != nil {
+			return filter, errors.New("max_mileage must be a number")
+		}
+		filter.MaxMileage = &value
+	}
+	if engineId := query.Get("engine_id"); engineId != "" {
+		value, err := uuid.Parse(engineId)
+		if err != nil {
+			return filter, errors.New("engine_id must be a valid id")
+		}
+		filter.EngineID = &value
+	}
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 0 {
+			return filter, errors.New("limit must be a non-negative number")
+		}
+		filter.Limit = value
+	}
+	if offset := query.Get("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			return filter, errors.New("offset must be a non-negative number")
+		}
+		filter.Offset = value
+	}
+	return filter, nil
+}
@@ -2,12 +2,16 @@ package engine
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"strings"
 
+	"github.com/Akmyrat17/carm/logging"
 	"github.com/Akmyrat17/carm/models"
 	"github.com/Akmyrat17/carm/service"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"go.opentelemetry.io/otel"
 )
@@ -31,13 +35,13 @@ func (e EngineHandler) GetEngineById(w http.ResponseWriter, r *http.Request) {
 	res, err := e.engineService.GetEngineById(ctx, id)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error getting engine by id: ", err)
+		logging.FromContext(ctx).Error("Error getting engine by id", "error", err)
 		return
 	}
 	body, err := json.Marshal(res)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling engine: ", err)
+		logging.FromContext(ctx).Error("Error marshalling engine", "error", err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -45,7 +49,48 @@ func (e EngineHandler) GetEngineById(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error writing response: ", err)
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
+
+// GetEngines lists the engines of the tenant, or only those named by a
+// comma separated "ids" query parameter.
+func (e EngineHandler) GetEngines(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("EngineHandler")
+	ctx, span := tracer.Start(r.Context(), "GetEngines-Handler")
+	defer span.End()
+
+	var res []models.Engine
+	var err error
+	if ids := r.URL.Query().Get("ids"); ids != "" {
+		idList := strings.Split(ids, ",")
+		for _, id := range idList {
+			if _, err := uuid.Parse(id); err != nil {
+				http.Error(w, fmt.Sprintf("invalid engine id %q", id), http.StatusBadRequest)
+				return
+			}
+		}
+		res, err = e.engineService.GetEnginesByIds(ctx, idList)
+	} else {
+		res, err = e.engineService.GetEngines(ctx)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting engines", "error", err)
+		return
+	}
+	body, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error marshalling engine", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(body)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
 		return
 	}
 }
@@ -59,7 +104,7 @@ func (e EngineHandler) CreateEngine(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error reading request body: ", err)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
 		return
 	}
 
@@ -67,20 +112,20 @@ func (e EngineHandler) CreateEngine(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, &engineReq)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		log.Println("Error unmarshalling request body: ", err)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
 		return
 	}
 
 	res, err := e.engineService.CreateEngine(ctx, &engineReq)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error creating engine: ", err)
+		logging.FromContext(ctx).Error("Error creating engine", "error", err)
 		return
 	}
 	body, err = json.Marshal(res)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling engine: ", err)
+		logging.FromContext(ctx).Error("Error marshalling engine", "error", err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -88,7 +133,7 @@ func (e EngineHandler) CreateEngine(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error writing response: ", err)
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
 		return
 	}
 }
@@ -104,7 +149,7 @@ func (e EngineHandler) UpdateEngine(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error reading request body: ", err)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
 		return
 	}
 
@@ -112,20 +157,20 @@ func (e EngineHandler) UpdateEngine(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, &engineReq)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		log.Println("Error unmarshalling request body: ", err)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
 		return
 	}
 
 	res, err := e.engineService.UpdateEngine(ctx, id, &engineReq)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error updating engine: ", err)
+		logging.FromContext(ctx).Error("Error updating engine", "error", err)
 		return
 	}
 	body, err = json.Marshal(res)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling engine: ", err)
+		logging.FromContext(ctx).Error("Error marshalling engine", "error", err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -133,7 +178,7 @@ func (e EngineHandler) UpdateEngine(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error writing response: ", err)
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
 		return
 	}
 }
@@ -148,14 +193,18 @@ func (e EngineHandler) DeleteEngine(w http.ResponseWriter, r *http.Request) {
 
 	res, err := e.engineService.DeleteEngine(ctx, id)
 	if err != nil {
+		if errors.Is(err, models.ErrEngineInUse) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error deleting engine: ", err)
+		logging.FromContext(ctx).Error("Error deleting engine", "error", err)
 		return
 	}
 	body, err := json.Marshal(res)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error marshalling engine: ", err)
+		logging.FromContext(ctx).Error("Error marshalling engine", "error", err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -163,7 +212,7 @@ func (e EngineHandler) DeleteEngine(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Println("Error writing response: ", err)
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
 		return
 	}
 }
@@ -0,0 +1,194 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Akmyrat17/carm/events"
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+)
+
+// keepAlive is how often an idle stream sends a heartbeat so proxies do
+// not close the connection.
+const keepAlive = 15 * time.Second
+
+type EventsHandler struct {
+	broker   *events.Broker
+	upgrader websocket.Upgrader
+}
+
+func NewEventsHandler(broker *events.Broker) *EventsHandler {
+	return &EventsHandler{broker: broker}
+}
+
+// StreamEvents streams car and engine changes as Server-Sent Events, or
+// over a WebSocket when the request asks for an upgrade. The entity and
+// brand query parameters filter the stream; Last-Event-ID (header or
+// query parameter) replays the changes a reconnecting client missed.
+func (h *EventsHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("EventsHandler")
+	ctx, span := tracer.Start(r.Context(), "StreamEvents-Handler")
+	// A stream stays open for as long as the client listens, so the span
+	// only covers setting it up.
+	span.End()
+
+	query := r.URL.Query()
+	filter := events.Filter{Entity: query.Get("entity"), Brand: query.Get("brand")}
+	if filter.Entity != "" && filter.Entity != events.EntityCar && filter.Entity != events.EntityEngine {
+		http.Error(w, "entity must be car or engine", http.StatusBadRequest)
+		return
+	}
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = query.Get("lastEventId")
+	}
+	var after int64
+	if lastEventId != "" {
+		var err error
+		after, err = strconv.ParseInt(lastEventId, 10, 64)
+		if err != nil {
+			http.Error(w, "Last-Event-ID must be an event id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		conn, err := h.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error upgrading to websocket", "error", err)
+			return
+		}
+		defer conn.Close()
+		sub, replay, err := h.broker.Subscribe(ctx, filter, after)
+		if errors.Is(err, events.ErrClosed) {
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"))
+			return
+		}
+		if err != nil {
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, ""))
+			logging.FromContext(ctx).Error("Error subscribing to events", "error", err)
+			return
+		}
+		defer sub.Close()
+		streamWebSocket(conn, sub, replay, h.broker)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	sub, replay, err := h.broker.Subscribe(ctx, filter, after)
+	if errors.Is(err, events.ErrClosed) {
+		http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error subscribing to events", "error", err)
+		return
+	}
+	defer sub.Close()
+
+	// The stream outlives the server's write timeout; the keep-alives
+	// notice clients that are gone.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		logging.FromContext(ctx).Error("Error clearing write deadline", "error", err)
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	for _, event := range replay {
+		if err := writeEvent(ctx, w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind or closed for shutdown; the
+				// client resumes from its last event id.
+				return
+			}
+			if err := writeEvent(ctx, w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(ctx context.Context, w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error marshalling event", "error", err)
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type(), data)
+	return err
+}
+
+// streamWebSocket sends each event as a JSON text message. The read loop
+// only exists to notice when the client goes away.
+func streamWebSocket(conn *websocket.Conn, sub *events.Subscription, replay []events.Event, broker *events.Broker) {
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, event := range replay {
+		if err := conn.WriteJSON(event); err != nil {
+			return
+		}
+	}
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "fell behind")
+				if broker.Closed() {
+					message = websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+				}
+				conn.WriteMessage(websocket.CloseMessage, message)
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(keepAlive)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package location
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/middleware"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+)
+
+type LocationHandler struct {
+	service service.LocationServiceInterface
+}
+
+func NewLocationHandler(service service.LocationServiceInterface) *LocationHandler {
+	return &LocationHandler{service: service}
+}
+
+func (h *LocationHandler) GetLocations(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LocationHandler")
+	ctx, span := tracer.Start(r.Context(), "GetLocations-Handler")
+	defer span.End()
+
+	res, err := h.service.GetLocations(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting locations", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *LocationHandler) GetLocationById(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LocationHandler")
+	ctx, span := tracer.Start(r.Context(), "GetLocationById-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	res, err := h.service.GetLocationById(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting location by id", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *LocationHandler) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LocationHandler")
+	ctx, span := tracer.Start(r.Context(), "CreateLocation-Handler")
+	defer span.End()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var locationReq models.LocationRequest
+	err = json.Unmarshal(body, &locationReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+
+	res, err := h.service.CreateLocation(ctx, &locationReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error creating location", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusCreated, res)
+}
+
+func (h *LocationHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LocationHandler")
+	ctx, span := tracer.Start(r.Context(), "UpdateLocation-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var locationReq models.LocationRequest
+	err = json.Unmarshal(body, &locationReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+
+	res, err := h.service.UpdateLocation(ctx, id, &locationReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error updating location", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *LocationHandler) DeleteLocation(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LocationHandler")
+	ctx, span := tracer.Start(r.Context(), "DeleteLocation-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	res, err := h.service.DeleteLocation(ctx, id)
+	if err != nil {
+		if errors.Is(err, models.ErrLocationInUse) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error deleting location", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *LocationHandler) GetLocationCounts(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LocationHandler")
+	ctx, span := tracer.Start(r.Context(), "GetLocationCounts-Handler")
+	defer span.End()
+
+	res, err := h.service.GetLocationCounts(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting location counts", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *LocationHandler) TransferCar(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LocationHandler")
+	ctx, span := tracer.Start(r.Context(), "TransferCar-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var transferReq models.CarTransferRequest
+	err = json.Unmarshal(body, &transferReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+	transferReq.MovedBy = middleware.Username(ctx)
+
+	res, err := h.service.TransferCar(ctx, carId, &transferReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error transferring car", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusCreated, res)
+}
+
+func (h *LocationHandler) GetCarMovements(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("LocationHandler")
+	ctx, span := tracer.Start(r.Context(), "GetCarMovements-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+
+	res, err := h.service.GetCarMovements(ctx, carId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting car movements", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, res interface{}) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error marshalling location", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
@@ -2,15 +2,33 @@ package login
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
-	"time"
 
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/middleware"
 	"github.com/Akmyrat17/carm/models"
-	"github.com/dgrijalva/jwt-go"
+	"github.com/Akmyrat17/carm/ratelimit"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
 )
 
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
+type LoginHandler struct {
+	users   service.UserServiceInterface
+	tenants service.TenantServiceInterface
+	auth    *middleware.Auth
+	lockout *ratelimit.Lockout
+}
+
+func NewLoginHandler(users service.UserServiceInterface, tenants service.TenantServiceInterface, auth *middleware.Auth, lockout *ratelimit.Lockout) *LoginHandler {
+	return &LoginHandler{users: users, tenants: tenants, auth: auth, lockout: lockout}
+}
+
+// Login signs in users of an existing tenant.
+// Accounts that fail too often are locked out for a while; the lockout
+// store being unavailable does not keep anyone from signing in.
+func (h *LoginHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var credentials models.Credentials
 
 	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
@@ -18,37 +36,62 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	valid := (credentials.Password == "admin" && credentials.Username == "admin")
+	tenantId := tenant.Default
+	if credentials.Tenant != "" {
+		parsed, err := uuid.Parse(credentials.Tenant)
+		if err != nil {
+			http.Error(w, "Invalid tenant", http.StatusBadRequest)
+			return
+		}
+		tenantId = parsed
+	}
+
+	ctx := r.Context()
+	if _, err := h.tenants.GetTenantById(ctx, tenantId.String()); err != nil {
+		if errors.Is(err, models.ErrTenantNotFound) {
+			http.Error(w, "Incorrect username or password", http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, "Failed to sign in", http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting tenant", "error", err)
+		return
+	}
+	account := tenantId.String() + ":" + credentials.Username
+	wait, err := h.lockout.Check(ctx, account)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error checking login lockout", "error", err)
+	}
+	if wait > 0 {
+		middleware.TooManyRequests(w, wait, "Too many failed sign-ins, try again later")
+		return
+	}
 
-	if !valid {
+	user, err := h.users.Authenticate(tenant.NewContext(r.Context(), tenantId), credentials.Username, credentials.Password)
+	if err != nil && !errors.Is(err, models.ErrWrongPassword) {
+		http.Error(w, "Failed to sign in", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error authenticating user", "error", err)
+		return
+	}
+	if err != nil || user.Username == "" {
+		if err := h.lockout.Fail(ctx, account); err != nil {
+			logging.FromContext(ctx).Error("Error recording failed sign-in", "error", err)
+		}
 		http.Error(w, "Incorrect username or password", http.StatusUnauthorized)
 		return
 	}
 
-	tokenString, err := GenerateToken(credentials.Username)
+	tokenString, err := h.auth.GenerateToken(user.Username, user.Role, tenantId)
 	if err != nil {
 		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
-		log.Println("error Generating token: ", err)
+		logging.FromContext(r.Context()).Error("Error generating token", "error", err)
 		return
 	}
 
+	if err := h.lockout.Succeed(ctx, account); err != nil {
+		logging.FromContext(ctx).Error("Error resetting failed sign-ins", "error", err)
+	}
+
 	response := map[string]string{"token": tokenString}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
-
-func GenerateToken(username string) (string, error) {
-	expiration := time.Now().Add(24 * time.Hour)
-	claims := &jwt.StandardClaims{
-		ExpiresAt: expiration.Unix(),
-		IssuedAt:  time.Now().Unix(),
-		Subject:   username,
-	}
-	token := jwt.NewWithClaims(jwt.GetSigningMethod("HS256"), claims)
-	signedToken, err := token.SignedString([]byte("some_valeu"))
-	if err != nil {
-		return "", err
-	}
-
-	return signedToken, nil
-}
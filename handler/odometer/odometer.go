@@ -0,0 +1,96 @@
+package odometer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/middleware"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+)
+
+type OdometerHandler struct {
+	service service.OdometerServiceInterface
+}
+
+func NewOdometerHandler(service service.OdometerServiceInterface) *OdometerHandler {
+	return &OdometerHandler{service: service}
+}
+
+func (h *OdometerHandler) GetOdometerReadings(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("OdometerHandler")
+	ctx, span := tracer.Start(r.Context(), "GetOdometerReadings-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+
+	res, err := h.service.GetOdometerReadings(ctx, carId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting odometer readings", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *OdometerHandler) CreateOdometerReading(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("OdometerHandler")
+	ctx, span := tracer.Start(r.Context(), "CreateOdometerReading-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var readingReq models.OdometerReadingRequest
+	err = json.Unmarshal(body, &readingReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+	if readingReq.Override && !middleware.IsAdmin(ctx) {
+		http.Error(w, "Only admins can override odometer readings", http.StatusForbidden)
+		return
+	}
+	readingReq.RecordedBy = middleware.Username(ctx)
+
+	res, err := h.service.CreateOdometerReading(ctx, carId, &readingReq)
+	if err != nil {
+		if errors.Is(err, models.ErrOdometerDecreased) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error creating odometer reading", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusCreated, res)
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, res interface{}) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error marshalling odometer reading", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
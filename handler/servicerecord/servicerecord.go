@@ -0,0 +1,167 @@
+package servicerecord
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+)
+
+type ServiceRecordHandler struct {
+	service service.ServiceRecordServiceInterface
+}
+
+func NewServiceRecordHandler(service service.ServiceRecordServiceInterface) *ServiceRecordHandler {
+	return &ServiceRecordHandler{service: service}
+}
+
+func (h *ServiceRecordHandler) GetServiceRecords(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("ServiceRecordHandler")
+	ctx, span := tracer.Start(r.Context(), "GetServiceRecords-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+
+	res, err := h.service.GetServiceRecords(ctx, carId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting service records", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *ServiceRecordHandler) GetServiceRecordById(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("ServiceRecordHandler")
+	ctx, span := tracer.Start(r.Context(), "GetServiceRecordById-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+	recordId := vars["recordId"]
+
+	res, err := h.service.GetServiceRecordById(ctx, carId, recordId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting service record by id", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *ServiceRecordHandler) CreateServiceRecord(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("ServiceRecordHandler")
+	ctx, span := tracer.Start(r.Context(), "CreateServiceRecord-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var recordReq models.ServiceRecordRequest
+	err = json.Unmarshal(body, &recordReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+
+	res, err := h.service.CreateServiceRecord(ctx, carId, &recordReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error creating service record", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusCreated, res)
+}
+
+func (h *ServiceRecordHandler) UpdateServiceRecord(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("ServiceRecordHandler")
+	ctx, span := tracer.Start(r.Context(), "UpdateServiceRecord-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+	recordId := vars["recordId"]
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var recordReq models.ServiceRecordRequest
+	err = json.Unmarshal(body, &recordReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+
+	res, err := h.service.UpdateServiceRecord(ctx, carId, recordId, &recordReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error updating service record", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *ServiceRecordHandler) DeleteServiceRecord(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("ServiceRecordHandler")
+	ctx, span := tracer.Start(r.Context(), "DeleteServiceRecord-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+	recordId := vars["recordId"]
+
+	res, err := h.service.DeleteServiceRecord(ctx, carId, recordId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error deleting service record", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *ServiceRecordHandler) GetNextServiceDue(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("ServiceRecordHandler")
+	ctx, span := tracer.Start(r.Context(), "GetNextServiceDue-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	carId := vars["id"]
+
+	res, err := h.service.GetNextServiceDue(ctx, carId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting next service due", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, res interface{}) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error marshalling service record", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
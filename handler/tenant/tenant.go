@@ -0,0 +1,144 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+)
+
+type TenantHandler struct {
+	service service.TenantServiceInterface
+}
+
+func NewTenantHandler(service service.TenantServiceInterface) *TenantHandler {
+	return &TenantHandler{service: service}
+}
+
+func (h *TenantHandler) GetTenants(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("TenantHandler")
+	ctx, span := tracer.Start(r.Context(), "GetTenants-Handler")
+	defer span.End()
+
+	res, err := h.service.GetTenants(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting tenants", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *TenantHandler) GetTenantById(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("TenantHandler")
+	ctx, span := tracer.Start(r.Context(), "GetTenantById-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	res, err := h.service.GetTenantById(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting tenant by id", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *TenantHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("TenantHandler")
+	ctx, span := tracer.Start(r.Context(), "CreateTenant-Handler")
+	defer span.End()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var tenantReq models.TenantRequest
+	err = json.Unmarshal(body, &tenantReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+
+	res, err := h.service.CreateTenant(ctx, &tenantReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error creating tenant", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusCreated, res)
+}
+
+func (h *TenantHandler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("TenantHandler")
+	ctx, span := tracer.Start(r.Context(), "UpdateTenant-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var tenantReq models.TenantRequest
+	err = json.Unmarshal(body, &tenantReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+
+	res, err := h.service.UpdateTenant(ctx, id, &tenantReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error updating tenant", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *TenantHandler) DeleteTenant(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("TenantHandler")
+	ctx, span := tracer.Start(r.Context(), "DeleteTenant-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	res, err := h.service.DeleteTenant(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error deleting tenant", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, res interface{}) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error marshalling tenant", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+)
+
+type WebhookHandler struct {
+	service service.WebhookServiceInterface
+}
+
+func NewWebhookHandler(service service.WebhookServiceInterface) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("WebhookHandler")
+	ctx, span := tracer.Start(r.Context(), "GetWebhooks-Handler")
+	defer span.End()
+
+	res, err := h.service.GetWebhooks(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting webhooks", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *WebhookHandler) GetWebhookById(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("WebhookHandler")
+	ctx, span := tracer.Start(r.Context(), "GetWebhookById-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	res, err := h.service.GetWebhookById(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting webhook by id", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("WebhookHandler")
+	ctx, span := tracer.Start(r.Context(), "CreateWebhook-Handler")
+	defer span.End()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var webhookReq models.WebhookRequest
+	err = json.Unmarshal(body, &webhookReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+
+	res, err := h.service.CreateWebhook(ctx, &webhookReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error creating webhook", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusCreated, res)
+}
+
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("WebhookHandler")
+	ctx, span := tracer.Start(r.Context(), "UpdateWebhook-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error reading request body", "error", err)
+		return
+	}
+
+	var webhookReq models.WebhookRequest
+	err = json.Unmarshal(body, &webhookReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logging.FromContext(ctx).Error("Error unmarshalling request body", "error", err)
+		return
+	}
+
+	res, err := h.service.UpdateWebhook(ctx, id, &webhookReq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error updating webhook", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("WebhookHandler")
+	ctx, span := tracer.Start(r.Context(), "DeleteWebhook-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	res, err := h.service.DeleteWebhook(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error deleting webhook", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("WebhookHandler")
+	ctx, span := tracer.Start(r.Context(), "GetDeliveries-Handler")
+	defer span.End()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	res, err := h.service.GetDeliveries(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error getting webhook deliveries", "error", err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, res)
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, res interface{}) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(ctx).Error("Error marshalling webhook", "error", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing response", "error", err)
+		return
+	}
+}
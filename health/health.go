@@ -0,0 +1,151 @@
+// Package health serves the liveness and readiness endpoints that
+// orchestrators probe. /healthz only says that the process is serving
+// requests; /readyz runs the registered dependency checks and reports the
+// status of each of them. Why a check fails only goes to the log, as the
+// probes answer without authentication.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+
+	// DefaultTimeout bounds every check when no other timeout is set.
+	DefaultTimeout = 2 * time.Second
+)
+
+// Check returns nil when the dependency is usable.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of one check.
+type Result struct {
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type namedCheck struct {
+	name     string
+	check    Check
+	optional bool
+}
+
+type Checker struct {
+	timeout time.Duration
+	checks  []namedCheck
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Require adds a check that must pass for the instance to be ready.
+func (c *Checker) Require(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Optional adds a check whose failure is only reported; it does not make
+// the instance unready.
+func (c *Checker) Optional(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check, optional: true})
+}
+
+// Run runs every check concurrently, each bounded by the timeout.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+	for i, check := range c.checks {
+		report.Checks[check.name] = results[i]
+		if results[i].Status != StatusOK && !check.optional {
+			report.Status = StatusFailing
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check namedCheck) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	err := check.check(ctx)
+	result := Result{Status: StatusOK, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusFailing
+		logging.FromContext(ctx).Error("Health check failing", "check", check.name, "optional", check.optional, "error", err)
+	}
+	return result
+}
+
+// Live answers 200 for as long as the process can serve HTTP at all.
+func (c *Checker) Live(w http.ResponseWriter, r *http.Request) {
+	writeReport(r.Context(), w, http.StatusOK, Report{Status: StatusOK})
+}
+
+// Ready answers 200 when every required check passes and 503 otherwise,
+// with the status and duration of each check in the body.
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeReport(r.Context(), w, status, report)
+}
+
+func writeReport(ctx context.Context, w http.ResponseWriter, status int, report Report) {
+	body, err := json.Marshal(report)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error marshalling health report", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// Ping checks that the database accepts connections.
+func Ping(db *sql.DB) Check {
+	return db.PingContext
+}
+
+// Dial checks that something listens on the TCP address, for dependencies
+// without a cheap health request such as the trace collector.
+func Dial(address string) Check {
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
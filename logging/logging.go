@@ -0,0 +1,69 @@
+// Package logging builds the slog logger of the server and carries it
+// through the layers in the request context. Middleware stores a logger
+// that already names the request; code further down logs through
+// FromContext, which adds the trace and span of ctx:
+//
+//	logging.FromContext(ctx).Error("Error getting car by id", "error", err)
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type contextKey struct{}
+
+// New returns a logger that writes records at or above level ("debug",
+// "info", "warn" or "error") in the given format.
+func New(w io.Writer, format string, level string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q, expected text or json", format)
+	}
+}
+
+// ParseLevel reads a level name such as "debug" or "warn".
+func ParseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return lvl, fmt.Errorf("invalid log level %q, expected debug, info, warn or error", level)
+	}
+	return lvl, nil
+}
+
+// NewContext returns a copy of ctx that carries logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger in ctx, or the default logger, with the
+// trace and span id of ctx when it is being traced.
+func FromContext(ctx context.Context) *slog.Logger {
+	logger, ok := ctx.Value(contextKey{}).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return logger
+	}
+	return logger.With("trace_id", spanContext.TraceID().String(), "span_id", spanContext.SpanID().String())
+}
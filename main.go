@@ -2,145 +2,334 @@ package main
 
 import (
 	"context"
-	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/Akmyrat17/carm/blob"
+	"github.com/Akmyrat17/carm/cache"
+	"github.com/Akmyrat17/carm/config"
 	"github.com/Akmyrat17/carm/driver"
+	"github.com/Akmyrat17/carm/events"
+	"github.com/Akmyrat17/carm/graphqlserver"
+	"github.com/Akmyrat17/carm/grpcserver"
+	apiKeyHandler "github.com/Akmyrat17/carm/handler/apikey"
+	attachmentHandler "github.com/Akmyrat17/carm/handler/attachment"
 	carHandler "github.com/Akmyrat17/carm/handler/car"
 	engineHandler "github.com/Akmyrat17/carm/handler/engine"
+	eventsHandler "github.com/Akmyrat17/carm/handler/events"
+	locationHandler "github.com/Akmyrat17/carm/handler/location"
 	loginHandler "github.com/Akmyrat17/carm/handler/login"
+	odometerHandler "github.com/Akmyrat17/carm/handler/odometer"
+	serviceRecordHandler "github.com/Akmyrat17/carm/handler/servicerecord"
+	tenantHandler "github.com/Akmyrat17/carm/handler/tenant"
+	webhookHandler "github.com/Akmyrat17/carm/handler/webhook"
+	"github.com/Akmyrat17/carm/health"
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/metrics"
 	"github.com/Akmyrat17/carm/middleware"
+	"github.com/Akmyrat17/carm/outbox"
+	"github.com/Akmyrat17/carm/ratelimit"
+	apiKeyService "github.com/Akmyrat17/carm/service/apikey"
+	attachmentService "github.com/Akmyrat17/carm/service/attachment"
 	carService "github.com/Akmyrat17/carm/service/car"
 	engineService "github.com/Akmyrat17/carm/service/engine"
+	locationService "github.com/Akmyrat17/carm/service/location"
+	odometerService "github.com/Akmyrat17/carm/service/odometer"
+	serviceRecordService "github.com/Akmyrat17/carm/service/servicerecord"
+	tenantService "github.com/Akmyrat17/carm/service/tenant"
+	userService "github.com/Akmyrat17/carm/service/user"
+	webhookService "github.com/Akmyrat17/carm/service/webhook"
+	"github.com/Akmyrat17/carm/store"
+	apiKeyStore "github.com/Akmyrat17/carm/store/apikey"
+	attachmentStore "github.com/Akmyrat17/carm/store/attachment"
+	"github.com/Akmyrat17/carm/store/cached"
 	carStore "github.com/Akmyrat17/carm/store/car"
 	engineStore "github.com/Akmyrat17/carm/store/engine"
+	idempotencyStore "github.com/Akmyrat17/carm/store/idempotency"
+	locationStore "github.com/Akmyrat17/carm/store/location"
+	odometerStore "github.com/Akmyrat17/carm/store/odometer"
+	serviceRecordStore "github.com/Akmyrat17/carm/store/servicerecord"
+	tenantStore "github.com/Akmyrat17/carm/store/tenant"
+	userStore "github.com/Akmyrat17/carm/store/user"
+	webhookStore "github.com/Akmyrat17/carm/store/webhook"
+	"github.com/Akmyrat17/carm/telemetry"
 	"github.com/gorilla/mux"
-	"github.com/joho/godotenv"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 func main() {
-	err := godotenv.Load()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatal("Error loading .env file: ", err)
+		log.Fatal("Error loading configuration: ", err)
+	}
+	if cfg.PrintConfig {
+		cfg.Print(os.Stdout)
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("Invalid configuration:\n", err)
 	}
+	logger, err := logging.New(os.Stderr, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		log.Fatal("Error setting up logging: ", err)
+	}
+	// Code without a request logger in its context, and the log package,
+	// write through it as well.
+	slog.SetDefault(logger)
 
-	// Open Telemetry tracing
-	traceProvider, err := startTracing()
+	// OpenTelemetry traces and metrics; the database is opened after
+	// this so that its queries are instrumented.
+	telemetryCfg := cfg.Telemetry()
+	providers, err := telemetry.Start(context.Background(), telemetryCfg)
 	if err != nil {
-		log.Fatal("Error starting tracing: ", err)
+		fatal("Error starting telemetry", "error", err)
 	}
+	otel.SetTracerProvider(providers.Tracer)
+	otel.SetMeterProvider(providers.Meter)
+	// Continue the traces of callers that send W3C trace headers, such as
+	// the carm client.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
-	defer func() {
-		if err := traceProvider.Shutdown(context.Background()); err != nil {
-			log.Printf("Failed to shut down tracing, %v", err)
-		}
-	}()
-	otel.SetTracerProvider(traceProvider)
+	logger.Info("Connecting to database", "host", cfg.DBHost, "name", cfg.DBName)
+	connectCtx, cancelConnect := context.WithTimeout(context.Background(), cfg.DBConnectTimeout)
+	db, err := driver.Connect(connectCtx, cfg.Database())
+	cancelConnect()
+	if err != nil {
+		fatal("Error connecting to database", "error", err)
+	}
+	logger.Info("Connected to database")
+	if err := driver.RegisterMetrics(db, cfg.DBName); err != nil {
+		fatal("Error registering database metrics", "error", err)
+	}
+	// The background workers query the tables as soon as they start.
+	if err := store.Migrate(context.Background(), db); err != nil {
+		fatal("Error executing schema file", "error", err)
+	}
+	background := newWorkers(logger)
+
+	blobStorage, err := blob.New(cfg.Blob())
+	if err != nil {
+		fatal("Error setting up blob storage", "error", err)
+	}
+	attachmentStore := attachmentStore.New(db)
+	attachmentService := attachmentService.NewAttachmentService(attachmentStore, blobStorage, cfg.AttachmentMaxSize)
+	attachmentHandler := attachmentHandler.NewAttachmentHandler(attachmentService, cfg.AttachmentMaxSize)
 
-	driver.InitDB()
-	defer driver.CloseDB()
+	eventBroker := events.NewBroker(events.DefaultHistory)
+	eventsHandler := eventsHandler.NewEventsHandler(eventBroker)
 
-	db := driver.GetDB()
+	webhookStore := webhookStore.New(db)
+	webhookService := webhookService.NewWebhookService(webhookStore)
+	webhookHandler := webhookHandler.NewWebhookHandler(webhookService)
+	background.Go(webhookService.Run)
+
+	// Car and engine changes land in the outbox together with the change
+	// itself; the relay hands them to the webhooks and the external sinks
+	// once across all instances, while every instance streams them to its
+	// own subscribers.
+	outboxSinks, err := outbox.NewSinks(cfg.Sinks())
+	if err != nil {
+		fatal("Error configuring outbox sinks", "error", err)
+	}
+	outboxSinks["webhooks"] = outbox.EventSink(webhookService.QueueDeliveries)
+	outboxRelay := outbox.NewRelay(db, outboxSinks)
+	background.Go(outboxRelay.Run)
+	outboxListener := outbox.NewListener(db, cfg.Database().DSN(), outbox.PublisherSink(eventBroker))
+	background.Go(outboxListener.Run)
+
+	// Car and engine lookups read through the cache; the stores that
+	// change cars or engines drop their entries.
+	lookupCache, err := cache.New(cfg.Cache())
+	if err != nil {
+		fatal("Error setting up cache", "error", err)
+	}
 	carStore := carStore.New(db)
-	carService := carService.NewCarService(carStore)
-	carHandler := carHandler.NewCarHandler(carService)
+	if err := prometheus.Register(metrics.NewInventoryCollector(carStore.CountCars)); err != nil {
+		fatal("Error registering car metrics", "error", err)
+	}
+	var cars store.CarStoreInterface = carStore
+	var engines store.EngineStoreInterface = engineStore.New(db)
+	var odometers store.OdometerStoreInterface = odometerStore.New(db)
+	var locations store.LocationStoreInterface = locationStore.New(db)
+	if lookupCache != nil {
+		background.Go(lookupCache.Run)
+		engines = cached.NewEngineStore(engines, lookupCache, cfg.CacheTTL)
+		cars = cached.NewCarStore(cars, engines, lookupCache, cfg.CacheTTL)
+		odometers = cached.NewOdometerStore(odometers, lookupCache, cfg.CacheTTL)
+		locations = cached.NewLocationStore(locations, lookupCache, cfg.CacheTTL)
+	}
 
-	engineStore := engineStore.New(db)
-	engineService := engineService.NewEngineService(engineStore)
+	carService := carService.NewCarService(cars, attachmentService)
+	carHandler := carHandler.NewCarHandler(carService, cfg.CarBatchMaxSize)
+
+	engineService := engineService.NewEngineService(engines)
 	engineHandler := engineHandler.NewEngineHandler(engineService)
 
-	router := mux.NewRouter()
-	router.Use(otelmux.Middleware("carm"))
-	router.Use(middleware.MetricMiddleware)
-	schemaFile := "store/schema.sql"
-	if err := executeSchemaFile(db, schemaFile); err != nil {
-		log.Fatal("Error executing schema file: ", err)
+	serviceIntervals, err := serviceRecordService.ParseIntervals(cfg.ServiceIntervals)
+	if err != nil {
+		fatal("Error parsing service intervals", "error", err)
 	}
+	serviceRecordStore := serviceRecordStore.New(db)
+	serviceRecordService := serviceRecordService.NewServiceRecordService(serviceRecordStore, cars, serviceIntervals)
+	serviceRecordHandler := serviceRecordHandler.NewServiceRecordHandler(serviceRecordService)
 
-	router.HandleFunc("/login", loginHandler.LoginHandler).Methods("POST")
+	odometerService := odometerService.NewOdometerService(odometers)
+	odometerHandler := odometerHandler.NewOdometerHandler(odometerService)
 
-	protected := router.PathPrefix("/").Subrouter()
-	protected.Use(middleware.AuthMiddleware)
-	protected.HandleFunc("/cars/{id}", carHandler.GetCarByID).Methods("GET")
-	protected.HandleFunc("/cars", carHandler.CreateCar).Methods("POST")
-	protected.HandleFunc("/cars", carHandler.GetCarByBrand).Methods("GET")
-	protected.HandleFunc("/cars/{id}", carHandler.UpdateCar).Methods("PUT")
-	protected.HandleFunc("/cars/{id}", carHandler.DeleteCar).Methods("DELETE")
+	locationService := locationService.NewLocationService(locations)
+	locationHandler := locationHandler.NewLocationHandler(locationService)
 
-	protected.HandleFunc("/engines/{id}", engineHandler.GetEngineById).Methods("GET")
-	protected.HandleFunc("/engines", engineHandler.CreateEngine).Methods("POST")
-	protected.HandleFunc("/engines/{id}", engineHandler.UpdateEngine).Methods("PUT")
-	protected.HandleFunc("/engines/{id}", engineHandler.DeleteEngine).Methods("DELETE")
+	tenantStore := tenantStore.New(db)
+	tenantService := tenantService.NewTenantService(tenantStore)
+	tenantHandler := tenantHandler.NewTenantHandler(tenantService)
 
-	router.Handle("/metrics", promhttp.Handler())
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	userStore := userStore.New(db)
+	userService := userService.NewUserService(userStore)
+	apiKeyStore := apiKeyStore.New(db)
+	apiKeyService := apiKeyService.NewAPIKeyService(apiKeyStore)
+	apiKeyHandler := apiKeyHandler.NewAPIKeyHandler(apiKeyService)
+	auth := middleware.NewAuth(cfg.JWTSecret, apiKeyService)
+
+	// Requests are limited per user, or per address before signing in;
+	// failed sign-ins count against the account.
+	rateLimitStore, err := ratelimit.New(cfg.RateLimitStore(), db)
+	if err != nil {
+		fatal("Error setting up rate limiting", "error", err)
 	}
-	add := fmt.Sprintf(":%s", port)
+	background.Go(rateLimitStore.Run)
+	rateLimitPolicy, err := ratelimit.ParsePolicy(cfg.RateLimit)
+	if err != nil {
+		fatal("Error parsing rate limit", "error", err)
+	}
+	rateLimitRoutes, err := middleware.ParseRateLimitRoutes(cfg.RateLimitRoutes)
+	if err != nil {
+		fatal("Error parsing route rate limits", "error", err)
+	}
+	rateLimit := middleware.NewRateLimit(rateLimitStore, middleware.RateLimitPolicies{Default: rateLimitPolicy, Routes: rateLimitRoutes})
+	lockout := ratelimit.NewLockout(rateLimitStore, cfg.LoginMaxFailures, cfg.LoginFailureWindow)
+	loginHandler := loginHandler.NewLoginHandler(userService, tenantService, auth, lockout)
 
-	fmt.Println("Starting server on port " + port)
-	log.Fatal(http.ListenAndServe(add, router))
-}
+	idempotency := middleware.NewIdempotency(idempotencyStore.New(db), cfg.IdempotencyTTL)
+	background.Go(idempotency.Run)
 
-func executeSchemaFile(db *sql.DB, schemaFile string) error {
-	schema, err := os.ReadFile(schemaFile)
+	graphqlSchema, err := graphqlserver.NewSchema(carService, engineService)
 	if err != nil {
-		return err
+		fatal("Error building GraphQL schema", "error", err)
 	}
-	_, err = db.Exec(string(schema))
+	graphqlHandler := graphqlserver.NewHandler(graphqlSchema, carService, engineService)
+
+	router := mux.NewRouter()
+	router.Use(otelmux.Middleware("carm"))
+	router.Use(middleware.RequestLogger(logger))
+	router.Use(middleware.MetricMiddleware)
+
+	routes{
+		auth:          auth,
+		rateLimit:     rateLimit,
+		idempotency:   idempotency,
+		login:         loginHandler,
+		cars:          carHandler,
+		serviceRecord: serviceRecordHandler,
+		odometer:      odometerHandler,
+		attachments:   attachmentHandler,
+		locations:     locationHandler,
+		engines:       engineHandler,
+		events:        eventsHandler,
+		webhooks:      webhookHandler,
+		graphql:       graphqlHandler,
+		tenants:       tenantHandler,
+		apiKeys:       apiKeyHandler,
+	}.register(router)
+
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
 	if err != nil {
-		return err
+		fatal("Error listening for gRPC", "error", err)
 	}
-	return nil
-}
+	grpcServer := grpcserver.New(carService, engineService, auth)
+	// The probes bypass authentication, tracing and the request metrics,
+	// which they would otherwise flood.
+	checker := health.NewChecker(cfg.HealthCheckTimeout)
+	checker.Require("database", health.Ping(db))
+	checker.Require("schema", func(ctx context.Context) error {
+		return store.CheckSchema(ctx, db)
+	})
+	if telemetryCfg.OTLP() {
+		checker.Optional("tracing", health.Dial(telemetryCfg.Address()))
+	}
+	background.Go(func(ctx context.Context) {
+		grpcServer.WatchHealth(ctx, checker)
+	})
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", checker.Live)
+	root.HandleFunc("GET /readyz", checker.Ready)
+	root.Handle("/", router)
 
-func startTracing() (*sdktrace.TracerProvider, error) {
-	header := map[string]string{
-		"Content-Type": "application/json",
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           root,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
 	}
+	// Event streams never go idle on their own, so Shutdown would wait for
+	// them until the deadline.
+	server.RegisterOnShutdown(eventBroker.Close)
 
-	exporter, err := otlptrace.New(
-		context.Background(),
-		otlptracehttp.NewClient(
-			otlptracehttp.WithEndpoint("jaeger:4318"),
-			otlptracehttp.WithHeaders(header),
-			otlptracehttp.WithInsecure(),
-		),
-	)
+	serveErrors := make(chan error, 2)
+	go func() {
+		logger.Info("Starting gRPC server", "port", cfg.GRPCPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			serveErrors <- fmt.Errorf("serving gRPC: %w", err)
+		}
+	}()
+	go func() {
+		logger.Info("Starting server", "port", cfg.Port)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serveErrors <- fmt.Errorf("serving HTTP: %w", err)
+		}
+	}()
 
-	if err != nil {
-		return nil, fmt.Errorf("Error creating new Exporter: %w", err)
-	}
-	res, err := resource.New(
-		context.Background(),
-		resource.WithAttributes(
-			semconv.ServiceNameKey.String("carm"),
-		),
-	)
-	if err != nil {
-		log.Fatalf("failed to create resource: %v", err)
+	signals, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	var serveErr error
+	select {
+	case <-signals.Done():
+		logger.Info("Shutting down")
+	case serveErr = <-serveErrors:
+		logger.Error("Shutting down", "error", serveErr)
 	}
+	// A second signal kills the process right away.
+	stop()
 
-	traceProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(
-			exporter,
-			sdktrace.WithMaxExportBatchSize(sdktrace.DefaultMaxExportBatchSize),
-			sdktrace.WithBatchTimeout(sdktrace.DefaultScheduleDelay),
-		),
-		sdktrace.WithResource(res),
-	)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	shutdown(ctx, server, grpcServer, background, providers, db)
+	if serveErr != nil {
+		os.Exit(1)
+	}
+}
 
-	return traceProvider, nil
+// fatal logs an error that keeps the server from starting and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
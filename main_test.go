@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	apiKeyHandler "github.com/Akmyrat17/carm/handler/apikey"
+	attachmentHandler "github.com/Akmyrat17/carm/handler/attachment"
+	carHandler "github.com/Akmyrat17/carm/handler/car"
+	engineHandler "github.com/Akmyrat17/carm/handler/engine"
+	eventsHandler "github.com/Akmyrat17/carm/handler/events"
+	locationHandler "github.com/Akmyrat17/carm/handler/location"
+	loginHandler "github.com/Akmyrat17/carm/handler/login"
+	odometerHandler "github.com/Akmyrat17/carm/handler/odometer"
+	serviceRecordHandler "github.com/Akmyrat17/carm/handler/servicerecord"
+	tenantHandler "github.com/Akmyrat17/carm/handler/tenant"
+	webhookHandler "github.com/Akmyrat17/carm/handler/webhook"
+	"github.com/Akmyrat17/carm/middleware"
+	"github.com/Akmyrat17/carm/openapi"
+	"github.com/gorilla/mux"
+)
+
+// TestRoutesDocumented registers the routes with handlers that are never
+// called and checks that the OpenAPI document covers every one of them.
+func TestRoutesDocumented(t *testing.T) {
+	router := mux.NewRouter()
+	routes{
+		auth:          &middleware.Auth{},
+		rateLimit:     &middleware.RateLimit{},
+		idempotency:   &middleware.Idempotency{},
+		login:         &loginHandler.LoginHandler{},
+		cars:          &carHandler.CarHandler{},
+		serviceRecord: &serviceRecordHandler.ServiceRecordHandler{},
+		odometer:      &odometerHandler.OdometerHandler{},
+		attachments:   &attachmentHandler.AttachmentHandler{},
+		locations:     &locationHandler.LocationHandler{},
+		engines:       &engineHandler.EngineHandler{},
+		events:        &eventsHandler.EventsHandler{},
+		webhooks:      &webhookHandler.WebhookHandler{},
+		graphql:       http.NotFoundHandler(),
+		tenants:       &tenantHandler.TenantHandler{},
+		apiKeys:       &apiKeyHandler.APIKeyHandler{},
+	}.register(router)
+
+	missing, err := openapi.MissingRoutes(router, apiDocument())
+	if err != nil {
+		t.Fatalf("MissingRoutes: %v", err)
+	}
+	if len(missing) > 0 {
+		t.Fatalf("routes missing from the OpenAPI document: %v", missing)
+	}
+}
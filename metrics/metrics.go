@@ -0,0 +1,142 @@
+// Package metrics holds the Prometheus metrics of the car domain, next to
+// the HTTP metrics recorded by middleware.MetricMiddleware.
+package metrics
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// MaxBrands bounds the brand label of the inventory gauge; brands
+	// beyond the most common ones are reported as OtherBrand. Brands are
+	// free text, so they could otherwise add series without limit.
+	MaxBrands  = 50
+	OtherBrand = "other"
+
+	inventoryTimeout = 5 * time.Second
+)
+
+var (
+	CarsCreated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "carm_cars_created_total",
+			Help: "Total number of cars created, by fuel type",
+		},
+		[]string{"fuel_type"},
+	)
+
+	CarsDeleted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "carm_cars_deleted_total",
+			Help: "Total number of cars deleted, by fuel type",
+		},
+		[]string{"fuel_type"},
+	)
+
+	EnginesCreated = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "carm_engines_created_total",
+			Help: "Total number of engines created",
+		},
+	)
+
+	EnginesDeleted = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "carm_engines_deleted_total",
+			Help: "Total number of engines deleted",
+		},
+	)
+
+	carsDesc = prometheus.NewDesc(
+		"carm_cars",
+		"Number of cars of all tenants, by brand and fuel type",
+		[]string{"brand", "fuel_type"},
+		nil,
+	)
+)
+
+func init() {
+	prometheus.MustRegister(CarsCreated, CarsDeleted, EnginesCreated, EnginesDeleted)
+}
+
+// CarCounter counts the cars by brand and fuel type, such as
+// CarStore.CountCars.
+type CarCounter func(ctx context.Context) ([]models.CarCount, error)
+
+// InventoryCollector reports the current number of cars on every scrape.
+type InventoryCollector struct {
+	count CarCounter
+}
+
+func NewInventoryCollector(count CarCounter) *InventoryCollector {
+	return &InventoryCollector{count: count}
+}
+
+func (c *InventoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- carsDesc
+}
+
+func (c *InventoryCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), inventoryTimeout)
+	defer cancel()
+	counts, err := c.count(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error counting cars for metrics", "error", err)
+		ch <- prometheus.NewInvalidMetric(carsDesc, err)
+		return
+	}
+	for _, count := range capBrands(counts) {
+		ch <- prometheus.MustNewConstMetric(carsDesc, prometheus.GaugeValue, float64(count.Count), count.Brand, count.FuelType)
+	}
+}
+
+// capBrands keeps the MaxBrands brands with the most cars and folds the
+// rest into OtherBrand.
+func capBrands(counts []models.CarCount) []models.CarCount {
+	totals := make(map[string]int64)
+	for _, count := range counts {
+		totals[count.Brand] += count.Count
+	}
+	if len(totals) <= MaxBrands {
+		return counts
+	}
+	brands := make([]string, 0, len(totals))
+	for brand := range totals {
+		brands = append(brands, brand)
+	}
+	sort.Slice(brands, func(i, j int) bool {
+		if totals[brands[i]] != totals[brands[j]] {
+			return totals[brands[i]] > totals[brands[j]]
+		}
+		return brands[i] < brands[j]
+	})
+	kept := make(map[string]bool, MaxBrands)
+	for _, brand := range brands[:MaxBrands] {
+		kept[brand] = true
+	}
+
+	type key struct{ brand, fuelType string }
+	merged := make(map[key]int64)
+	var order []key
+	for _, count := range counts {
+		k := key{count.Brand, count.FuelType}
+		if !kept[count.Brand] {
+			k.brand = OtherBrand
+		}
+		if _, ok := merged[k]; !ok {
+			order = append(order, k)
+		}
+		merged[k] += count.Count
+	}
+	capped := make([]models.CarCount, 0, len(order))
+	for _, k := range order {
+		capped = append(capped, models.CarCount{Brand: k.brand, FuelType: k.fuelType, Count: merged[k]})
+	}
+	return capped
+}
@@ -2,41 +2,285 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/tenant"
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+)
+
+const (
+	// RoleAdmin administers the tenant of the user.
+	RoleAdmin = "admin"
+	// RolePlatformAdmin administers the tenants themselves and may act for
+	// any of them.
+	RolePlatformAdmin = "platform-admin"
+)
+
+type contextKey string
+
+const (
+	usernameKey contextKey = "username"
+	roleKey     contextKey = "role"
+	apiKeyKey   contextKey = "apiKey"
 )
 
 type Claims struct {
 	Username string `json:"username"`
+	Role     string `json:"role"`
+	TenantID string `json:"tenant_id,omitempty"`
 	jwt.StandardClaims
 }
 
-var jwtKey = []byte("some_valeu")
+var (
+	ErrMissingToken = errors.New("Authorization header required")
+	ErrInvalidToken = errors.New("Invalid Token")
+	ErrInvalidKey   = errors.New("Invalid API key")
+)
+
+// ForbiddenError is returned for valid tokens that may not act for the
+// requested tenant.
+type ForbiddenError struct {
+	Reason string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Reason
+}
+
+// APIKeyAuthenticator looks up the API keys sent in the X-API-Key header.
+// It returns a zero APIKey for unknown and revoked keys.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (models.APIKey, error)
+}
+
+// Auth signs and checks the JWTs issued by /login and checks API keys
+// with apiKeys, which may be nil to refuse them.
+type Auth struct {
+	key     []byte
+	apiKeys APIKeyAuthenticator
+}
+
+func NewAuth(secret string, apiKeys APIKeyAuthenticator) *Auth {
+	return &Auth{key: []byte(secret), apiKeys: apiKeys}
+}
+
+// GenerateToken issues a token for the user that is valid for a day.
+func (a *Auth) GenerateToken(username string, role string, tenantId uuid.UUID) (string, error) {
+	expiration := time.Now().Add(24 * time.Hour)
+	claims := &Claims{
+		Username: username,
+		Role:     role,
+		TenantID: tenantId.String(),
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: expiration.Unix(),
+			IssuedAt:  time.Now().Unix(),
+			Subject:   username,
+		},
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod("HS256"), claims)
+	return token.SignedString(a.key)
+}
 
-func AuthMiddleware(next http.Handler) http.Handler {
+func (a *Auth) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+		ctx, err := a.Authenticate(r.Context(), r.Header.Get("Authorization"), r.Header.Get("X-API-Key"), r.Header.Get("X-Tenant-ID"))
+		if err != nil {
+			var forbidden *ForbiddenError
+			switch {
+			case errors.As(err, &forbidden):
+				http.Error(w, err.Error(), http.StatusForbidden)
+			case Unauthenticated(err):
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+				logging.FromContext(r.Context()).Error("Error authenticating request", "error", err)
+			}
 			return
 		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+
+	})
+}
+
+// Authenticate validates an API key, or a "Bearer <jwt>" authorization
+// value when there is none, and returns ctx carrying the user, role and
+// tenant of the key or token. It is shared by the HTTP middleware and the
+// gRPC interceptors.
+func (a *Auth) Authenticate(ctx context.Context, authorization string, apiKey string, tenantHeader string) (context.Context, error) {
+	if apiKey != "" {
+		return a.authenticateKey(ctx, apiKey, tenantHeader)
+	}
+	if authorization == "" {
+		return ctx, ErrMissingToken
+	}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer")
+	tokenString := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer"))
 
-		claims := &Claims{}
+	claims := &Claims{}
 
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return a.key, nil
+	})
+
+	if err != nil || !token.Valid {
+		return ctx, ErrInvalidToken
+	}
+	username := claims.Username
+	if username == "" {
+		username = claims.Subject
+	}
+	tenantId, err := requestTenant(tenantHeader, claims.Role, claims.TenantID)
+	if err != nil {
+		return ctx, &ForbiddenError{Reason: err.Error()}
+	}
+	ctx = context.WithValue(ctx, usernameKey, username)
+	ctx = context.WithValue(ctx, roleKey, claims.Role)
+	ctx = tenant.NewContext(ctx, tenantId)
+	return ctx, nil
+}
+
+// authenticateKey acts as the API key: its name is the user, its scopes
+// are the permissions and the admin scope gives it the admin role. A key
+// only ever acts for the tenant it was created in.
+func (a *Auth) authenticateKey(ctx context.Context, apiKey string, tenantHeader string) (context.Context, error) {
+	if a.apiKeys == nil {
+		return ctx, ErrInvalidKey
+	}
+	key, err := a.apiKeys.AuthenticateAPIKey(ctx, apiKey)
+	if err != nil {
+		return ctx, err
+	}
+	if key.ID == uuid.Nil {
+		return ctx, ErrInvalidKey
+	}
+	if tenantHeader != "" {
+		return ctx, &ForbiddenError{Reason: "API keys cannot choose the tenant"}
+	}
+	role := models.UserRoleMember
+	if key.HasScope(models.APIKeyScopeAdmin) {
+		role = RoleAdmin
+	}
+	ctx = context.WithValue(ctx, usernameKey, "api-key:"+key.Name)
+	ctx = context.WithValue(ctx, roleKey, role)
+	ctx = context.WithValue(ctx, apiKeyKey, key)
+	ctx = tenant.NewContext(ctx, key.TenantID)
+	return ctx, nil
+}
+
+// Unauthenticated reports whether err says the credentials are missing or
+// wrong, rather than that checking them failed.
+func Unauthenticated(err error) bool {
+	return errors.Is(err, ErrMissingToken) || errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrInvalidKey)
+}
 
-		if err != nil || !token.Valid {
-			http.Error(w, "Invalid Token", http.StatusUnauthorized)
+// requestTenant picks the tenant the request acts for: the token's tenant,
+// or the default tenant for tokens issued without one. Platform admins may
+// act for any tenant by naming it in the X-Tenant-ID header.
+func requestTenant(header string, role string, tokenTenant string) (uuid.UUID, error) {
+	if header != "" {
+		if role != RolePlatformAdmin {
+			return uuid.Nil, errors.New("only platform admins can choose the tenant")
+		}
+		id, err := uuid.Parse(header)
+		if err != nil {
+			return uuid.Nil, errors.New("invalid X-Tenant-ID header")
+		}
+		return id, nil
+	}
+	if tokenTenant == "" {
+		return tenant.Default, nil
+	}
+	id, err := uuid.Parse(tokenTenant)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid tenant in token")
+	}
+	return id, nil
+}
+
+// AdminOnly rejects requests from users that do not administer the tenant
+// of the request. It must run after Auth.Middleware.
+func AdminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsAdmin(r.Context()) {
+			http.Error(w, "Admin role required", http.StatusForbidden)
 			return
 		}
-		ctx := context.WithValue(r.Context(), "username", claims.Username)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r)
+	})
+}
 
+// PlatformAdminOnly rejects requests from users without the platform-admin
+// role. It must run after Auth.Middleware.
+func PlatformAdminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsPlatformAdmin(r.Context()) {
+			http.Error(w, "Platform admin role required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
 	})
 }
+
+// Username returns the authenticated user stored in ctx by Auth.
+func Username(ctx context.Context) string {
+	username, _ := ctx.Value(usernameKey).(string)
+	return username
+}
+
+// IsAdmin reports whether the authenticated user administers the tenant
+// of the request, as its admin or as a platform admin.
+func IsAdmin(ctx context.Context) bool {
+	role, _ := ctx.Value(roleKey).(string)
+	return role == RoleAdmin || role == RolePlatformAdmin
+}
+
+// IsPlatformAdmin reports whether the authenticated user has the
+// platform-admin role.
+func IsPlatformAdmin(ctx context.Context) bool {
+	role, _ := ctx.Value(roleKey).(string)
+	return role == RolePlatformAdmin
+}
+
+// APIKey returns the API key the request was authenticated with, if any.
+func APIKey(ctx context.Context) (models.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey).(models.APIKey)
+	return key, ok
+}
+
+// MethodScope returns the API key scope an HTTP method needs: read for
+// the safe methods, write for the others.
+func MethodScope(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return models.APIKeyScopeRead
+	}
+	return models.APIKeyScopeWrite
+}
+
+// MethodScopes rejects API keys that lack the scope of the request method.
+// It must run after Auth.Middleware. Handlers that check the scope of what
+// they do themselves, such as GraphQL, go without it.
+func MethodScopes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if scope := MethodScope(r.Method); !HasScope(r.Context(), scope) {
+			http.Error(w, "API key lacks the "+scope+" scope", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HasScope reports whether the request may use scope. Users signed in with
+// a password have every scope their role allows.
+func HasScope(ctx context.Context, scope string) bool {
+	if key, ok := APIKey(ctx); ok {
+		return key.HasScope(scope)
+	}
+	return scope != models.APIKeyScopeAdmin || IsAdmin(ctx)
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+)
+
+// DefaultIdempotencyTTL is how long a response is replayed for its key.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentBodySize caps the request bodies that are read to hash
+// them.
+const maxIdempotentBodySize = 4 << 20
+
+// idempotencyInProgressTimeout is how long a claimed key may stay without
+// a response before another request may take it over.
+const idempotencyInProgressTimeout = time.Minute
+
+// Idempotency makes create endpoints safe to retry. The first request
+// with an Idempotency-Key header claims the key and its response is
+// stored; later requests with the same key and body get that response
+// back instead of creating another resource. Keys belong to the user or
+// API key that sent them.
+type Idempotency struct {
+	store store.IdempotencyStoreInterface
+	ttl   time.Duration
+}
+
+func NewIdempotency(store store.IdempotencyStoreInterface, ttl time.Duration) *Idempotency {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &Idempotency{store: store, ttl: ttl}
+}
+
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(statusCode int) {
+	if rw.statusCode == 0 {
+		rw.statusCode = statusCode
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.statusCode == 0 {
+		rw.statusCode = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+			return
+		}
+		ctx := r.Context()
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			logging.FromContext(ctx).Error("Error reading request body", "error", err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.Sum256(body)
+
+		now := time.Now()
+		record := models.IdempotencyRecord{
+			Owner:       idempotencyOwner(ctx),
+			Key:         key,
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			RequestHash: hex.EncodeToString(hash[:]),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(i.ttl),
+		}
+		claimed, err := i.store.ClaimIdempotencyKey(ctx, &record, now.Add(-idempotencyInProgressTimeout))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			logging.FromContext(ctx).Error("Error claiming idempotency key", "error", err)
+			return
+		}
+		if !claimed {
+			i.replay(w, r, record)
+			return
+		}
+
+		rw := &recordingWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		// The client may be gone by now, but the outcome still has to be
+		// recorded.
+		ctx = context.WithoutCancel(ctx)
+		if rw.statusCode == 0 || rw.statusCode >= http.StatusInternalServerError {
+			// Server errors are not final; release the key for a retry.
+			if err := i.store.DeleteIdempotencyKey(ctx, record.Owner, record.Key, record.Method, record.Path); err != nil {
+				logging.FromContext(ctx).Error("Error releasing idempotency key", "error", err)
+			}
+			return
+		}
+		record.StatusCode = rw.statusCode
+		record.ContentType = rw.Header().Get("Content-Type")
+		record.Body = rw.body.Bytes()
+		if err := i.store.CompleteIdempotencyKey(ctx, &record); err != nil {
+			logging.FromContext(ctx).Error("Error storing idempotent response", "error", err)
+		}
+	})
+}
+
+func (i *Idempotency) replay(w http.ResponseWriter, r *http.Request, record models.IdempotencyRecord) {
+	stored, err := i.store.GetIdempotencyKey(r.Context(), record.Owner, record.Key, record.Method, record.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Error getting idempotency key", "error", err)
+		return
+	}
+	if stored.RequestHash != record.RequestHash {
+		http.Error(w, "Idempotency-Key was already used with a different request body", http.StatusUnprocessableEntity)
+		return
+	}
+	if stored.StatusCode == 0 {
+		http.Error(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+		return
+	}
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.StatusCode)
+	if _, err := w.Write(stored.Body); err != nil {
+		logging.FromContext(r.Context()).Error("Error writing response", "error", err)
+	}
+}
+
+// idempotencyOwner names the API key or user of the request, so that
+// callers in one tenant cannot replay each other's responses.
+func idempotencyOwner(ctx context.Context) string {
+	if key, ok := APIKey(ctx); ok {
+		return "api-key:" + key.ID.String()
+	}
+	return "user:" + Username(ctx)
+}
+
+// Run removes expired keys every hour until ctx is cancelled.
+func (i *Idempotency) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if _, err := i.store.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+			logging.FromContext(ctx).Error("Error deleting expired idempotency keys", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
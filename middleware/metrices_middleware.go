@@ -1,18 +1,25 @@
 package middleware
 
 import (
-	// "github.com/armon/go-metrics/prometheus"
+	"bufio"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// unmatchedRoute labels requests that did not match a route, so that
+// scanners probing random paths cannot create new series.
+const unmatchedRoute = "unmatched"
+
 var (
 	requestCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "http_requests_total",
-			Help: "Total number of http requests",
+			Help: "Total number of HTTP requests",
 		},
 		[]string{"path", "method"},
 	)
@@ -20,7 +27,7 @@ var (
 	requestDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name: "http_requests_duration_seconds",
-			Help: "DUration of http requests in seconds",
+			Help: "Duration of HTTP requests in seconds",
 		},
 		[]string{"path", "method"},
 	)
@@ -28,36 +35,108 @@ var (
 	statusCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "http_response_total",
-			Help: "Total Number of Http resp[onse by status code]",
+			Help: "Total number of HTTP responses by status code",
 		},
 		[]string{"path", "method", "status_code"},
 	)
+
+	requestsInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests being served, including open event streams",
+		},
+		[]string{"path", "method"},
+	)
+
+	responseSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "Size of HTTP response bodies in bytes",
+			Buckets: prometheus.ExponentialBuckets(128, 4, 8),
+		},
+		[]string{"path", "method"},
+	)
 )
 
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	size       int64
 }
 
+// MetricMiddleware records request metrics labelled with the route
+// template, such as /cars/{id}, rather than the requested path.
 func MetricMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		path := routeTemplate(r)
+		inFlight := requestsInFlight.WithLabelValues(path, r.Method)
+		inFlight.Inc()
+		defer inFlight.Dec()
 
 		ww := &responseWriter{ResponseWriter: w}
 		next.ServeHTTP(ww, r)
+		if ww.statusCode == 0 {
+			// Nothing was written; net/http answers 200.
+			ww.statusCode = http.StatusOK
+		}
 
 		duration := time.Since(start).Seconds()
-		requestCounter.WithLabelValues(r.URL.Path, r.Method)
-		requestDuration.WithLabelValues(r.URL.Path, r.Method).Observe(duration)
-		statusCounter.WithLabelValues(r.URL.Path, r.Method, http.StatusText(ww.statusCode)).Inc()
+		requestCounter.WithLabelValues(path, r.Method).Inc()
+		requestDuration.WithLabelValues(path, r.Method).Observe(duration)
+		statusCounter.WithLabelValues(path, r.Method, strconv.Itoa(ww.statusCode)).Inc()
+		responseSize.WithLabelValues(path, r.Method).Observe(float64(ww.size))
 	})
 }
 
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return unmatchedRoute
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return unmatchedRoute
+	}
+	return template
+}
+
 func (re *responseWriter) WriteHeader(status_code int) {
-	re.statusCode = status_code
+	if re.statusCode == 0 {
+		re.statusCode = status_code
+	}
 	re.ResponseWriter.WriteHeader(status_code)
 }
 
+func (re *responseWriter) Write(b []byte) (int, error) {
+	if re.statusCode == 0 {
+		re.statusCode = http.StatusOK
+	}
+	n, err := re.ResponseWriter.Write(b)
+	re.size += int64(n)
+	return n, err
+}
+
+// Flush and Hijack pass through to the wrapped writer so streaming
+// endpoints keep working behind the middleware.
+func (re *responseWriter) Flush() {
+	if flusher, ok := re.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (re *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(re.ResponseWriter).Hijack()
+	if err == nil && re.statusCode == 0 {
+		re.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+func (re *responseWriter) Unwrap() http.ResponseWriter {
+	return re.ResponseWriter
+}
+
 func init() {
-	prometheus.MustRegister(requestCounter, requestDuration, statusCounter)
+	prometheus.MustRegister(requestCounter, requestDuration, statusCounter, requestsInFlight, responseSize)
 }
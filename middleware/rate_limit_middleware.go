@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/ratelimit"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultRateLimitPolicy names the bucket shared by the routes without a
+// policy of their own.
+const defaultRateLimitPolicy = "default"
+
+var rateLimitedCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_requests_rate_limited_total",
+		Help: "Total number of HTTP requests refused by the rate limit",
+	},
+	[]string{"path", "method"},
+)
+
+func init() {
+	prometheus.MustRegister(rateLimitedCounter)
+}
+
+// RateLimitPolicies are the policies of RateLimit. Routes maps
+// "METHOD /route/template" to the policy of that route, which gets a
+// bucket of its own; the other routes share a bucket under Default.
+type RateLimitPolicies struct {
+	Default ratelimit.Policy
+	Routes  map[string]ratelimit.Policy
+}
+
+// ParseRateLimitRoutes reads route policies written as
+// "METHOD /route/template=policy", such as "POST /login=10/1m".
+func ParseRateLimitRoutes(entries []string) (map[string]ratelimit.Policy, error) {
+	routes := make(map[string]ratelimit.Policy, len(entries))
+	for _, entry := range entries {
+		route, value, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath || !strings.HasPrefix(strings.TrimSpace(path), "/") {
+			return nil, fmt.Errorf("invalid route rate limit %q, expected METHOD /path=policy", entry)
+		}
+		policy, err := ratelimit.ParsePolicy(value)
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = policy
+	}
+	return routes, nil
+}
+
+// RateLimit refuses requests with 429 Too Many Requests once their client
+// has used up its bucket. Clients are the authenticated user, or the
+// remote address before authentication, so the middleware runs after
+// Auth.Middleware on protected routes. Every limited response carries the
+// RateLimit-* headers; refused ones also carry Retry-After.
+type RateLimit struct {
+	store    ratelimit.Store
+	policies RateLimitPolicies
+}
+
+func NewRateLimit(store ratelimit.Store, policies RateLimitPolicies) *RateLimit {
+	return &RateLimit{store: store, policies: policies}
+}
+
+func (l *RateLimit) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		path := routeTemplate(r)
+		name := r.Method + " " + path
+		policy, ok := l.policies.Routes[name]
+		if !ok {
+			name, policy = defaultRateLimitPolicy, l.policies.Default
+		}
+		if policy.Unlimited() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result, err := l.store.Take(ctx, rateLimitClient(r)+"|"+name, policy, 1)
+		if err != nil {
+			// Limiting is best effort; an unreachable backend must not
+			// take the API down with it.
+			logging.FromContext(ctx).Error("Error taking rate limit token", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		header := w.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", policy.Limit, ceilSeconds(policy.Period), policy.Burst))
+		if !result.Allowed {
+			rateLimitedCounter.WithLabelValues(path, r.Method).Inc()
+			TooManyRequests(w, result.RetryAfter, "Rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TooManyRequests answers 429 with a Retry-After of wait.
+func TooManyRequests(w http.ResponseWriter, wait time.Duration, message string) {
+	w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(wait), 1)))
+	http.Error(w, message, http.StatusTooManyRequests)
+}
+
+// rateLimitClient names the client of r: its API key, or the user of its
+// tenant once authenticated, otherwise the remote address.
+func rateLimitClient(r *http.Request) string {
+	if key, ok := APIKey(r.Context()); ok {
+		return "key:" + key.ID.String()
+	}
+	if username := Username(r.Context()); username != "" {
+		tenantId, _ := tenant.FromContext(r.Context())
+		return "user:" + tenantId.String() + ":" + username
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
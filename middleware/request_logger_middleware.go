@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// RequestLogger gives every request an id, taken from the X-Request-ID
+// header when the caller sent a usable one, and puts a logger naming it
+// into the request context. The id is echoed in the response and recorded
+// on the request span.
+func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestId := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestId) {
+				requestId = uuid.NewString()
+			}
+			w.Header().Set(RequestIDHeader, requestId)
+			trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("request.id", requestId))
+
+			ctx := logging.NewContext(r.Context(), logger.With("request_id", requestId))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// validRequestID accepts ids of printable ASCII without spaces, so that
+// callers cannot forge log lines through the header.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// APIKeyScopeRead allows GET requests.
+	APIKeyScopeRead = "read"
+	// APIKeyScopeWrite allows requests that change data.
+	APIKeyScopeWrite = "write"
+	// APIKeyScopeAdmin gives the key the admin role; it implies the other
+	// scopes.
+	APIKeyScopeAdmin = "admin"
+)
+
+var APIKeyScopes = []string{APIKeyScopeRead, APIKeyScopeWrite, APIKeyScopeAdmin}
+
+// APIKey lets a machine client act for its tenant without signing in.
+// Only a SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	Name     string    `json:"name"`
+	// Prefix is the start of the key, to tell keys apart.
+	Prefix string   `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	// Key is only returned when the key is created.
+	Key        string     `json:"key,omitempty"`
+	KeyHash    string     `json:"-"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type APIKeyRequest struct {
+	Name string `json:"name"`
+	// Scopes default to read.
+	Scopes    []string `json:"scopes"`
+	CreatedBy string   `json:"-"`
+}
+
+// Revoked reports whether the key may no longer be used.
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// HasScope reports whether the key grants scope.
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == APIKeyScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidateAPIKeyRequest(keyReq APIKeyRequest) error {
+	if keyReq.Name == "" {
+		return errors.New("api key name cannot be empty")
+	}
+	if len(keyReq.Scopes) == 0 {
+		return errors.New("api key needs at least one scope")
+	}
+	for _, scope := range keyReq.Scopes {
+		if !validAPIKeyScope(scope) {
+			return fmt.Errorf("unknown api key scope %q", scope)
+		}
+	}
+	return nil
+}
+
+func validAPIKeyScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
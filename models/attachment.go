@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Attachment struct {
+	ID           uuid.UUID `json:"id"`
+	CarID        uuid.UUID `json:"car_id"`
+	Kind         string    `json:"kind"`
+	FileName     string    `json:"file_name"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	HasThumbnail bool      `json:"has_thumbnail"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+var (
+	// ErrInvalidUpload is wrapped by the errors of uploads that are
+	// rejected for what they contain.
+	ErrInvalidUpload = errors.New("invalid upload")
+	// ErrUnsupportedContentType is wrapped by the error of uploads that are
+	// not one of the accepted file types.
+	ErrUnsupportedContentType = errors.New("unsupported file type")
+)
+
+type AttachmentUpload struct {
+	Kind        string
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+func ValidateAttachmentUpload(upload AttachmentUpload, maxSize int64) error {
+	if err := validateAttachmentKind(upload.Kind); err != nil {
+		return err
+	}
+	if upload.FileName == "" {
+		return fmt.Errorf("%w: file name cannot be empty", ErrInvalidUpload)
+	}
+	if len(upload.Content) == 0 {
+		return fmt.Errorf("%w: file cannot be empty", ErrInvalidUpload)
+	}
+	if int64(len(upload.Content)) > maxSize {
+		return fmt.Errorf("%w: file cannot be larger than %d bytes", ErrInvalidUpload, maxSize)
+	}
+	if err := validateAttachmentContentType(upload.ContentType); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateAttachmentKind(kind string) error {
+	validKinds := []string{"photo", "registration", "inspection", "other"}
+	for _, validKind := range validKinds {
+		if validKind == kind {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: invalid attachment kind selected, please select one of the following: photo, registration, inspection, other", ErrInvalidUpload)
+}
+
+func validateAttachmentContentType(contentType string) error {
+	validContentTypes := []string{"image/jpeg", "image/png", "image/gif", "application/pdf"}
+	for _, validType := range validContentTypes {
+		if validType == contentType {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %s, only JPEG, PNG, GIF and PDF files are accepted", ErrUnsupportedContentType, contentType)
+}
@@ -9,15 +9,17 @@ import (
 )
 
 type Car struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	Year      string    `json:"year"`
-	FuelType  string    `json:"fuel_type"`
-	Price     float64   `json:"price"`
-	Engine    Engine    `json:"engine"`
-	Brand     string    `json:"brand"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Year       string     `json:"year"`
+	FuelType   string     `json:"fuel_type"`
+	Price      float64    `json:"price"`
+	Engine     Engine     `json:"engine"`
+	Brand      string     `json:"brand"`
+	Mileage    int64      `json:"mileage"`
+	LocationID *uuid.UUID `json:"location_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
 }
 
 type CarRequest struct {
@@ -27,6 +29,98 @@ type CarRequest struct {
 	Brand    string  `json:"brand"`
 	Price    float64 `json:"price"`
 	Engine   Engine  `json:"engine"`
+	// LocationID places a new car; existing cars move through transfers.
+	LocationID *uuid.UUID `json:"location_id"`
+}
+
+// CarFilter narrows down a car listing. Zero values mean "no filter".
+type CarFilter struct {
+	Brand      string
+	IsEngine   bool
+	MinMileage *int64
+	MaxMileage *int64
+	// LocationID matches cars at the location or any of its lots.
+	LocationID *uuid.UUID
+	EngineID   *uuid.UUID
+	// Limit caps the number of cars returned when positive; Offset skips
+	// that many cars of the ordered listing.
+	Limit  int
+	Offset int
+}
+
+// CarCount is the number of cars of one brand and fuel type.
+type CarCount struct {
+	Brand    string
+	FuelType string
+	Count    int64
+}
+
+const (
+	CarBatchCreate = "create"
+	CarBatchUpdate = "update"
+	CarBatchDelete = "delete"
+)
+
+const (
+	CarBatchSucceeded = "succeeded"
+	CarBatchFailed    = "failed"
+	// CarBatchSkipped marks operations that were not applied because
+	// another operation of an atomic batch failed.
+	CarBatchSkipped = "skipped"
+	// CarBatchRolledBack marks operations of an atomic batch that went
+	// through but were undone when a later operation failed.
+	CarBatchRolledBack = "rolled_back"
+)
+
+// CarBatchRequest is a list of car operations run in one transaction.
+// Atomic batches, the default, are applied completely or not at all;
+// otherwise every operation that succeeds is kept.
+type CarBatchRequest struct {
+	Atomic     *bool               `json:"atomic"`
+	Operations []CarBatchOperation `json:"operations"`
+}
+
+type CarBatchOperation struct {
+	// Op is create, update or delete.
+	Op string `json:"op"`
+	// ID is the car to update or delete.
+	ID string `json:"id,omitempty"`
+	// Car is the new car for create and update.
+	Car *CarRequest `json:"car,omitempty"`
+}
+
+type CarBatchResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Status string `json:"status"`
+	Car    *Car   `json:"car,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type CarBatchResponse struct {
+	Atomic bool `json:"atomic"`
+	// Committed is false when an atomic batch was rolled back.
+	Committed bool             `json:"committed"`
+	Results   []CarBatchResult `json:"results"`
+}
+
+func ValidateCarBatchOperation(op CarBatchOperation) error {
+	switch op.Op {
+	case CarBatchCreate:
+	case CarBatchUpdate, CarBatchDelete:
+		if _, err := uuid.Parse(op.ID); err != nil {
+			return errors.New("id must be a valid car id")
+		}
+	default:
+		return errors.New("op must be one of create, update or delete")
+	}
+	if op.Op == CarBatchDelete {
+		return nil
+	}
+	if op.Car == nil {
+		return errors.New("car cannot be empty")
+	}
+	return CarValidateRequest(*op.Car)
 }
 
 func CarValidateRequest(carReq CarRequest) error {
@@ -84,13 +178,23 @@ func validateBrand(brand string) error {
 	return nil
 }
 
-func validateFuelType(fuelType string) error {
-	validateFuelTypes := []string{"Gasoline", "Diesel", "Electric", "Hybrid"}
-	for _, validType := range validateFuelTypes {
+// FuelTypes are the fuel types a car can have.
+var FuelTypes = []string{"Gasoline", "Diesel", "Electric", "Hybrid"}
+
+// ValidFuelType reports whether fuelType is one of FuelTypes.
+func ValidFuelType(fuelType string) bool {
+	for _, validType := range FuelTypes {
 		if validType == fuelType {
-			return nil
+			return true
 		}
 	}
+	return false
+}
+
+func validateFuelType(fuelType string) error {
+	if ValidFuelType(fuelType) {
+		return nil
+	}
 	return errors.New("invalid fuel type selected, please select one of the following: Gasoline, Diesel, Electric, Hybrid")
 }
 
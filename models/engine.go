@@ -7,6 +7,11 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrEngineInUse is returned when deleting an engine that cars still
+// have; deleting them along with it would skip their attachments and
+// events.
+var ErrEngineInUse = errors.New("engine is still used by cars; delete them or change their engine first")
+
 type Engine struct {
 	ID            uuid.UUID `json:"id"`
 	Displacement  int64     `json:"displacement"`
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IdempotencyRecord remembers the response to a request sent with an
+// Idempotency-Key header. StatusCode is zero while the first request is
+// still being handled. Owner is the user or API key that sent the
+// request; keys of different owners never collide.
+type IdempotencyRecord struct {
+	Owner       string
+	Key         string
+	Method      string
+	Path        string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
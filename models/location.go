@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LocationTypeDealership = "dealership"
+	LocationTypeLot        = "lot"
+)
+
+// ErrLocationInUse is returned when deleting a location that still has
+// cars or lots.
+var ErrLocationInUse = errors.New("location still has cars or lots assigned")
+
+// Location is either a dealership or a lot belonging to a dealership.
+type Location struct {
+	ID        uuid.UUID  `json:"id"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	Name      string     `json:"name"`
+	Type      string     `json:"type"`
+	Address   string     `json:"address"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type LocationRequest struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+	Name     string     `json:"name"`
+	Type     string     `json:"type"`
+	Address  string     `json:"address"`
+}
+
+// LocationCount holds the number of cars parked directly at a location and
+// the number including all of its lots.
+type LocationCount struct {
+	LocationID uuid.UUID  `json:"location_id"`
+	ParentID   *uuid.UUID `json:"parent_id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Cars       int64      `json:"cars"`
+	TotalCars  int64      `json:"total_cars"`
+}
+
+type CarTransferRequest struct {
+	LocationID uuid.UUID `json:"location_id"`
+	Reason     string    `json:"reason"`
+	MovedBy    string    `json:"-"`
+}
+
+// CarMovement records a transfer. The locations are nil for cars that had
+// no location before and for locations deleted since.
+type CarMovement struct {
+	ID             uuid.UUID  `json:"id"`
+	CarID          uuid.UUID  `json:"car_id"`
+	FromLocationID *uuid.UUID `json:"from_location_id"`
+	ToLocationID   *uuid.UUID `json:"to_location_id"`
+	Reason         string     `json:"reason"`
+	MovedBy        string     `json:"moved_by"`
+	MovedAt        time.Time  `json:"moved_at"`
+}
+
+func ValidateLocationRequest(locationReq LocationRequest) error {
+	if locationReq.Name == "" {
+		return errors.New("location name cannot be empty")
+	}
+	switch locationReq.Type {
+	case LocationTypeDealership:
+		if locationReq.ParentID != nil {
+			return errors.New("a dealership cannot belong to another location")
+		}
+	case LocationTypeLot:
+		if locationReq.ParentID == nil || *locationReq.ParentID == uuid.Nil {
+			return errors.New("a lot must belong to a dealership")
+		}
+	default:
+		return errors.New("invalid location type selected, please select one of the following: dealership, lot")
+	}
+	return nil
+}
+
+func ValidateCarTransferRequest(transferReq CarTransferRequest) error {
+	if transferReq.LocationID == uuid.Nil {
+		return errors.New("location id cannot be empty")
+	}
+	return nil
+}
@@ -2,5 +2,8 @@ package models
 
 type Credentials struct {
 	Username string `json:"username"`
-	Password string `json: "password`
+	Password string `json:"password"`
+	// Tenant is the id of the organisation to sign in to; empty means the
+	// default tenant.
+	Tenant string `json:"tenant"`
 }
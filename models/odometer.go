@@ -0,0 +1,44 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrOdometerDecreased is returned when a reading is lower than the car's
+// current mileage and no override was requested.
+var ErrOdometerDecreased = errors.New("odometer reading cannot be lower than the current mileage")
+
+type OdometerReading struct {
+	ID             uuid.UUID `json:"id"`
+	CarID          uuid.UUID `json:"car_id"`
+	Reading        int64     `json:"reading"`
+	RecordedAt     time.Time `json:"recorded_at"`
+	Override       bool      `json:"override"`
+	OverrideReason string    `json:"override_reason,omitempty"`
+	RecordedBy     string    `json:"recorded_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type OdometerReadingRequest struct {
+	Reading        int64     `json:"reading"`
+	RecordedAt     time.Time `json:"recorded_at"`
+	Override       bool      `json:"override"`
+	OverrideReason string    `json:"override_reason"`
+	RecordedBy     string    `json:"-"`
+}
+
+func ValidateOdometerReadingRequest(readingReq OdometerReadingRequest) error {
+	if err := validateOdometer(readingReq.Reading); err != nil {
+		return err
+	}
+	if readingReq.RecordedAt.After(time.Now()) {
+		return errors.New("odometer reading cannot be recorded in the future")
+	}
+	if readingReq.Override && readingReq.OverrideReason == "" {
+		return errors.New("override reason cannot be empty when overriding an odometer reading")
+	}
+	return nil
+}
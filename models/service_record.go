@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ServiceRecord struct {
+	ID        uuid.UUID `json:"id"`
+	CarID     uuid.UUID `json:"car_id"`
+	Date      time.Time `json:"date"`
+	Odometer  int64     `json:"odometer"`
+	WorkType  string    `json:"work_type"`
+	Cost      float64   `json:"cost"`
+	Notes     string    `json:"notes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ServiceRecordRequest struct {
+	Date     time.Time `json:"date"`
+	Odometer int64     `json:"odometer"`
+	WorkType string    `json:"work_type"`
+	Cost     float64   `json:"cost"`
+	Notes    string    `json:"notes"`
+}
+
+// ServiceInterval is how far a car may be driven, or how long it may go,
+// before its next service is due.
+type ServiceInterval struct {
+	Kilometers int64 `json:"kilometers"`
+	Months     int   `json:"months"`
+}
+
+type NextServiceDue struct {
+	CarID           uuid.UUID       `json:"car_id"`
+	FuelType        string          `json:"fuel_type"`
+	Interval        ServiceInterval `json:"interval"`
+	LastServiceDate *time.Time      `json:"last_service_date"`
+	LastOdometer    int64           `json:"last_odometer"`
+	DueDate         time.Time       `json:"due_date"`
+	DueOdometer     int64           `json:"due_odometer"`
+	Overdue         bool            `json:"overdue"`
+}
+
+func ValidateServiceRecordRequest(recordReq ServiceRecordRequest) error {
+	if err := validateServiceDate(recordReq.Date); err != nil {
+		return err
+	}
+	if err := validateOdometer(recordReq.Odometer); err != nil {
+		return err
+	}
+	if err := validateWorkType(recordReq.WorkType); err != nil {
+		return err
+	}
+	if err := validateCost(recordReq.Cost); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateServiceDate(date time.Time) error {
+	if date.IsZero() {
+		return errors.New("service date cannot be empty")
+	}
+	if date.After(time.Now()) {
+		return errors.New("service date cannot be in the future")
+	}
+	return nil
+}
+
+func validateOdometer(odometer int64) error {
+	if odometer < 0 {
+		return errors.New("odometer cannot be negative")
+	}
+	return nil
+}
+
+func validateWorkType(workType string) error {
+	validWorkTypes := []string{"Oil Change", "Inspection", "Repair", "Tyre Change", "Brake Service", "Other"}
+	for _, validType := range validWorkTypes {
+		if validType == workType {
+			return nil
+		}
+	}
+	return errors.New("invalid work type selected, please select one of the following: Oil Change, Inspection, Repair, Tyre Change, Brake Service, Other")
+}
+
+func validateCost(cost float64) error {
+	if cost < 0 {
+		return errors.New("cost cannot be negative")
+	}
+	return nil
+}
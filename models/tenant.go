@@ -0,0 +1,28 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrTenantNotFound = errors.New("tenant not found in database")
+
+type Tenant struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TenantRequest struct {
+	Name string `json:"name"`
+}
+
+func ValidateTenantRequest(tenantReq TenantRequest) error {
+	if tenantReq.Name == "" {
+		return errors.New("tenant name cannot be empty")
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// UserRolePlatformAdmin manages the tenants and may act for any of
+	// them. Platform admins belong to the default tenant.
+	UserRolePlatformAdmin = "platform-admin"
+	UserRoleAdmin         = "admin"
+	UserRoleMember        = "member"
+)
+
+// User signs in with a username and password and acts for its tenant.
+type User struct {
+	ID           uuid.UUID `json:"id"`
+	TenantID     uuid.UUID `json:"tenant_id"`
+	Username     string    `json:"username"`
+	Role         string    `json:"role"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type UserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Role defaults to member.
+	Role string `json:"role"`
+}
+
+// ErrWrongPassword is returned when a known user signs in with another
+// password.
+var ErrWrongPassword = errors.New("wrong password")
+
+const minPasswordLength = 8
+
+func ValidateUserRequest(userReq UserRequest) error {
+	if userReq.Username == "" {
+		return errors.New("username cannot be empty")
+	}
+	if len(userReq.Password) < minPasswordLength {
+		return errors.New("password must be at least 8 characters")
+	}
+	if userReq.Role != UserRolePlatformAdmin && userReq.Role != UserRoleAdmin && userReq.Role != UserRoleMember {
+		return errors.New("role must be platform-admin, admin or member")
+	}
+	return nil
+}
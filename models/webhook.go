@@ -0,0 +1,120 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook subscribes a URL to change events. An empty Events list
+// subscribes to every event.
+type Webhook struct {
+	ID     uuid.UUID `json:"id"`
+	URL    string    `json:"url"`
+	Events []string  `json:"events"`
+	Active bool      `json:"active"`
+	// Secret signs the deliveries. It is only returned when the webhook is
+	// created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Active defaults to true for new webhooks.
+	Active *bool `json:"active"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	EventType      string          `json:"event_type"`
+	EventKey       string          `json:"event_key"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status"`
+	LastError      string          `json:"last_error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	// URL and Secret of the webhook, filled in for the delivery worker.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+func ValidateWebhookRequest(webhookReq WebhookRequest, eventTypes []string) error {
+	if webhookReq.URL == "" {
+		return errors.New("webhook url cannot be empty")
+	}
+	target, err := url.Parse(webhookReq.URL)
+	if err != nil || target.Host == "" || (target.Scheme != "http" && target.Scheme != "https") {
+		return errors.New("webhook url must be an absolute http or https url")
+	}
+	// Names are checked again when a delivery connects, as they can
+	// resolve to anything.
+	host := strings.ToLower(target.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("webhook url must not point to this server")
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !PublicAddress(ip) {
+		return fmt.Errorf("webhook url must not point to the non-public address %s", ip)
+	}
+	for _, event := range webhookReq.Events {
+		if !contains(eventTypes, event) {
+			return fmt.Errorf("unknown webhook event %q, please select from the following: %v", event, eventTypes)
+		}
+	}
+	return nil
+}
+
+// nonPublicPrefixes are the special-purpose ranges netip has no method
+// for.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// PublicAddress reports whether webhooks may be delivered to ip. Loopback,
+// private, link-local and other special-purpose addresses would let a
+// webhook reach the network carm runs in.
+func PublicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Akmyrat17/carm/logging"
+)
+
+// SpecHandler serves the document as JSON.
+func SpecHandler(document map[string]interface{}) http.HandlerFunc {
+	body, err := json.Marshal(document)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			logging.FromContext(r.Context()).Error("Error marshalling openapi document", "error", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(body); err != nil {
+			logging.FromContext(r.Context()).Error("Error writing response", "error", err)
+		}
+	}
+}
+
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>carm API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// DocsHandler serves a Swagger UI page for /openapi.json.
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(swaggerUI)); err != nil {
+		logging.FromContext(r.Context()).Error("Error writing response", "error", err)
+	}
+}
@@ -0,0 +1,209 @@
+// Package openapi describes the HTTP API as an OpenAPI 3 document. Request
+// and response schemas are derived from the models by reflection, so they
+// follow the json tags of the structs the handlers actually encode.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Operation documents one method on one route. Operations that are not
+// Public can answer 401 and 429; RateLimited marks public ones that can
+// answer 429 as well.
+type Operation struct {
+	Method       string
+	Path         string
+	Tag          string
+	Summary      string
+	Public       bool
+	RateLimited  bool
+	Query        []Param
+	Header       []Param
+	Request      interface{}
+	RequestType  string
+	Status       int
+	Response     interface{}
+	ResponseType string
+}
+
+// Param is a query string or header parameter.
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+type builder struct {
+	schemas map[string]interface{}
+}
+
+var pathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Document builds the OpenAPI document for the given operations.
+func Document(title string, version string, operations []Operation) map[string]interface{} {
+	d := &builder{schemas: map[string]interface{}{}}
+	paths := map[string]interface{}{}
+	for _, op := range operations {
+		item, ok := paths[op.Path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = d.operation(op)
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": d.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+				"apiKeyAuth": map[string]interface{}{
+					"type": "apiKey",
+					"in":   "header",
+					"name": "X-API-Key",
+				},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearerAuth": []interface{}{}},
+			map[string]interface{}{"apiKeyAuth": []interface{}{}},
+		},
+	}
+}
+
+func (d *builder) operation(op Operation) map[string]interface{} {
+	operation := map[string]interface{}{
+		"tags":    []string{op.Tag},
+		"summary": op.Summary,
+	}
+	if op.Public {
+		operation["security"] = []interface{}{}
+	}
+
+	var parameters []interface{}
+	for _, match := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, param := range op.Query {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        param.Name,
+			"in":          "query",
+			"description": param.Description,
+			"schema":      map[string]interface{}{"type": param.Type},
+		})
+	}
+	for _, param := range op.Header {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        param.Name,
+			"in":          "header",
+			"description": param.Description,
+			"schema":      map[string]interface{}{"type": param.Type},
+		})
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if op.Request != nil {
+		contentType := op.RequestType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				contentType: map[string]interface{}{"schema": d.schemaFor(op.Request)},
+			},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := map[string]interface{}{"description": http.StatusText(status)}
+	if op.Response != nil {
+		contentType := op.ResponseType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		response["content"] = map[string]interface{}{
+			contentType: map[string]interface{}{"schema": d.schemaFor(op.Response)},
+		}
+	}
+	responses := map[string]interface{}{fmt.Sprint(status): response}
+	if !op.Public {
+		responses["401"] = map[string]interface{}{"description": http.StatusText(http.StatusUnauthorized)}
+	}
+	if !op.Public || op.RateLimited {
+		responses["429"] = map[string]interface{}{
+			"description": http.StatusText(http.StatusTooManyRequests),
+			"headers": map[string]interface{}{
+				"Retry-After": map[string]interface{}{
+					"description": "Seconds to wait before retrying",
+					"schema":      map[string]interface{}{"type": "integer"},
+				},
+			},
+		}
+	}
+	operation["responses"] = responses
+	return operation
+}
+
+// schemaFor accepts either a Go value, whose type is described, or a ready
+// made schema given as a map.
+func (d *builder) schemaFor(v interface{}) map[string]interface{} {
+	if schema, ok := v.(map[string]interface{}); ok {
+		return schema
+	}
+	return d.schemaRef(reflect.TypeOf(v))
+}
+
+// MissingRoutes lists the "METHOD /path" pairs registered on router that
+// have no operation in the document.
+func MissingRoutes(router *mux.Router, document map[string]interface{}) ([]string, error) {
+	paths, _ := document["paths"].(map[string]interface{})
+	var missing []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Routes without a method matcher are subrouter prefixes or
+			// infrastructure endpoints such as /metrics.
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		item, _ := paths[template].(map[string]interface{})
+		for _, method := range methods {
+			if _, ok := item[strings.ToLower(method)]; !ok {
+				missing = append(missing, method+" "+template)
+			}
+		}
+		return nil
+	})
+	sort.Strings(missing)
+	return missing, err
+}
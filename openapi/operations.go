@@ -0,0 +1,139 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/Akmyrat17/carm/health"
+	"github.com/Akmyrat17/carm/models"
+)
+
+var (
+	tokenResponse = map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"token": map[string]interface{}{"type": "string"}},
+	}
+	attachmentUpload = map[string]interface{}{
+		"type":     "object",
+		"required": []string{"file", "kind"},
+		"properties": map[string]interface{}{
+			"file": map[string]interface{}{"type": "string", "format": "binary"},
+			"kind": map[string]interface{}{"type": "string", "enum": []string{"photo", "registration", "inspection", "other"}},
+		},
+	}
+	graphqlRequest = map[string]interface{}{
+		"type":     "object",
+		"required": []string{"query"},
+		"properties": map[string]interface{}{
+			"query":         map[string]interface{}{"type": "string"},
+			"operationName": map[string]interface{}{"type": "string"},
+			"variables":     map[string]interface{}{"type": "object"},
+		},
+	}
+	binaryFile  = map[string]interface{}{"type": "string", "format": "binary"}
+	anyDocument = map[string]interface{}{"type": "object"}
+	eventStream = map[string]interface{}{"type": "string"}
+	htmlPage    = map[string]interface{}{"type": "string"}
+)
+
+var carFilterParams = []Param{
+	{Name: "brand", Type: "string", Description: "Only cars of this brand"},
+	{Name: "isEngine", Type: "boolean", Description: "Include the engine of each car"},
+	{Name: "min_mileage", Type: "integer", Description: "Minimum current mileage"},
+	{Name: "max_mileage", Type: "integer", Description: "Maximum current mileage"},
+	{Name: "location_id", Type: "string", Description: "Only cars at this location or its lots"},
+	{Name: "engine_id", Type: "string", Description: "Only cars with this engine"},
+	{Name: "limit", Type: "integer", Description: "Maximum number of cars; all when unset"},
+	{Name: "offset", Type: "integer", Description: "Number of cars to skip"},
+}
+
+var engineListParams = []Param{
+	{Name: "ids", Type: "string", Description: "Comma separated engine ids to return instead of all engines; an invalid id answers 400"},
+}
+
+var idempotencyHeader = []Param{
+	{Name: "Idempotency-Key", Type: "string", Description: "Replays the original response when the same user or API key retries the request with the same key and body; a different body is rejected with 422"},
+}
+
+var eventParams = []Param{
+	{Name: "entity", Type: "string", Description: "Only events of this entity: car or engine"},
+	{Name: "brand", Type: "string", Description: "Only events of cars of this brand"},
+	{Name: "lastEventId", Type: "string", Description: "Replay events after this id; the Last-Event-ID header takes precedence"},
+}
+
+var graphqlParams = []Param{
+	{Name: "query", Type: "string", Description: "GraphQL document; mutations need POST, and queries nested too deeply or too complex get a 400"},
+	{Name: "operationName", Type: "string", Description: "Operation to run when the document has several"},
+	{Name: "variables", Type: "string", Description: "JSON object of variable values"},
+}
+
+// Operations documents every route registered by the server. Keep it in
+// step with routes.go; TestRoutesDocumented fails when a route is missing.
+var Operations = []Operation{
+	{Method: "POST", Path: "/login", Tag: "auth", Summary: "Exchange credentials for a JWT; repeated failures lock the account for a while", Public: true, RateLimited: true, Request: models.Credentials{}, Response: tokenResponse},
+	{Method: "GET", Path: "/openapi.json", Tag: "docs", Summary: "This OpenAPI document", Public: true, Response: anyDocument},
+	{Method: "GET", Path: "/docs", Tag: "docs", Summary: "Swagger UI", Public: true, Response: htmlPage, ResponseType: "text/html"},
+	{Method: "GET", Path: "/healthz", Tag: "health", Summary: "Liveness; 200 while the process serves HTTP", Public: true, Response: health.Report{}},
+	{Method: "GET", Path: "/readyz", Tag: "health", Summary: "Readiness; 200 when the required checks pass, 503 otherwise, with the status and duration of each check", Public: true, Response: health.Report{}},
+
+	{Method: "GET", Path: "/cars", Tag: "cars", Summary: "List cars", Query: carFilterParams, Response: []models.Car{}},
+	{Method: "POST", Path: "/cars", Tag: "cars", Summary: "Create a car", Header: idempotencyHeader, Request: models.CarRequest{}, Status: http.StatusCreated, Response: models.Car{}},
+	{Method: "POST", Path: "/cars/batch", Tag: "cars", Summary: "Create, update and delete cars in one transaction; a rolled back atomic batch answers 422", Header: idempotencyHeader, Request: models.CarBatchRequest{}, Response: models.CarBatchResponse{}},
+	{Method: "GET", Path: "/cars/{id}", Tag: "cars", Summary: "Get a car", Response: models.Car{}},
+	{Method: "PUT", Path: "/cars/{id}", Tag: "cars", Summary: "Update a car", Request: models.CarRequest{}, Response: models.Car{}},
+	{Method: "DELETE", Path: "/cars/{id}", Tag: "cars", Summary: "Delete a car and its attachments", Response: models.Car{}},
+
+	{Method: "GET", Path: "/cars/{id}/service-records", Tag: "service records", Summary: "List service records of a car", Response: []models.ServiceRecord{}},
+	{Method: "POST", Path: "/cars/{id}/service-records", Tag: "service records", Summary: "Add a service record", Request: models.ServiceRecordRequest{}, Status: http.StatusCreated, Response: models.ServiceRecord{}},
+	{Method: "GET", Path: "/cars/{id}/service-records/next-due", Tag: "service records", Summary: "When the car is next due for service", Response: models.NextServiceDue{}},
+	{Method: "GET", Path: "/cars/{id}/service-records/{recordId}", Tag: "service records", Summary: "Get a service record", Response: models.ServiceRecord{}},
+	{Method: "PUT", Path: "/cars/{id}/service-records/{recordId}", Tag: "service records", Summary: "Update a service record", Request: models.ServiceRecordRequest{}, Response: models.ServiceRecord{}},
+	{Method: "DELETE", Path: "/cars/{id}/service-records/{recordId}", Tag: "service records", Summary: "Delete a service record", Response: models.ServiceRecord{}},
+
+	{Method: "GET", Path: "/cars/{id}/odometer", Tag: "odometer", Summary: "List odometer readings of a car", Response: []models.OdometerReading{}},
+	{Method: "POST", Path: "/cars/{id}/odometer", Tag: "odometer", Summary: "Record an odometer reading; lower readings need an admin override", Request: models.OdometerReadingRequest{}, Status: http.StatusCreated, Response: models.OdometerReading{}},
+
+	{Method: "GET", Path: "/cars/{id}/attachments", Tag: "attachments", Summary: "List attachments of a car", Response: []models.Attachment{}},
+	{Method: "POST", Path: "/cars/{id}/attachments", Tag: "attachments", Summary: "Upload an attachment", Request: attachmentUpload, RequestType: "multipart/form-data", Status: http.StatusCreated, Response: models.Attachment{}},
+	{Method: "GET", Path: "/cars/{id}/attachments/{attachmentId}", Tag: "attachments", Summary: "Download an attachment", Response: binaryFile, ResponseType: "application/octet-stream"},
+	{Method: "GET", Path: "/cars/{id}/attachments/{attachmentId}/thumbnail", Tag: "attachments", Summary: "Download the thumbnail of an image attachment", Response: binaryFile, ResponseType: "image/jpeg"},
+	{Method: "DELETE", Path: "/cars/{id}/attachments/{attachmentId}", Tag: "attachments", Summary: "Delete an attachment", Response: models.Attachment{}},
+
+	{Method: "POST", Path: "/cars/{id}/transfers", Tag: "locations", Summary: "Move a car to another location", Request: models.CarTransferRequest{}, Status: http.StatusCreated, Response: models.CarMovement{}},
+	{Method: "GET", Path: "/cars/{id}/movements", Tag: "locations", Summary: "Movement history of a car", Response: []models.CarMovement{}},
+	{Method: "GET", Path: "/locations", Tag: "locations", Summary: "List locations", Response: []models.Location{}},
+	{Method: "POST", Path: "/locations", Tag: "locations", Summary: "Create a dealership or lot", Request: models.LocationRequest{}, Status: http.StatusCreated, Response: models.Location{}},
+	{Method: "GET", Path: "/locations/counts", Tag: "locations", Summary: "Number of cars per location", Response: []models.LocationCount{}},
+	{Method: "GET", Path: "/locations/{id}", Tag: "locations", Summary: "Get a location", Response: models.Location{}},
+	{Method: "PUT", Path: "/locations/{id}", Tag: "locations", Summary: "Update a location", Request: models.LocationRequest{}, Response: models.Location{}},
+	{Method: "DELETE", Path: "/locations/{id}", Tag: "locations", Summary: "Delete a location without cars or lots; 409 otherwise. Movements keep no reference to it", Response: models.Location{}},
+	{Method: "GET", Path: "/locations/{id}/cars", Tag: "locations", Summary: "List cars at a location and its lots", Query: carFilterParams, Response: []models.Car{}},
+
+	{Method: "GET", Path: "/engines", Tag: "engines", Summary: "List engines", Query: engineListParams, Response: []models.Engine{}},
+	{Method: "GET", Path: "/engines/{id}", Tag: "engines", Summary: "Get an engine", Response: models.Engine{}},
+	{Method: "POST", Path: "/engines", Tag: "engines", Summary: "Create an engine", Header: idempotencyHeader, Request: models.EngineRequest{}, Response: models.Engine{}},
+	{Method: "PUT", Path: "/engines/{id}", Tag: "engines", Summary: "Update an engine", Request: models.EngineRequest{}, Response: models.Engine{}},
+	{Method: "DELETE", Path: "/engines/{id}", Tag: "engines", Summary: "Delete an engine that no car uses; 409 otherwise", Response: models.Engine{}},
+
+	{Method: "GET", Path: "/events", Tag: "events", Summary: "Stream car and engine changes as Server-Sent Events or over a WebSocket", Query: eventParams, Response: eventStream, ResponseType: "text/event-stream"},
+
+	{Method: "GET", Path: "/webhooks", Tag: "webhooks", Summary: "List webhooks (admin)", Response: []models.Webhook{}},
+	{Method: "POST", Path: "/webhooks", Tag: "webhooks", Summary: "Subscribe a public URL to events; the response carries the signing secret (admin)", Request: models.WebhookRequest{}, Status: http.StatusCreated, Response: models.Webhook{}},
+	{Method: "GET", Path: "/webhooks/{id}", Tag: "webhooks", Summary: "Get a webhook (admin)", Response: models.Webhook{}},
+	{Method: "PUT", Path: "/webhooks/{id}", Tag: "webhooks", Summary: "Update a webhook (admin)", Request: models.WebhookRequest{}, Response: models.Webhook{}},
+	{Method: "DELETE", Path: "/webhooks/{id}", Tag: "webhooks", Summary: "Delete a webhook and its deliveries (admin)", Response: models.Webhook{}},
+	{Method: "GET", Path: "/webhooks/{id}/deliveries", Tag: "webhooks", Summary: "Latest deliveries of a webhook (admin)", Response: []models.WebhookDelivery{}},
+
+	{Method: "GET", Path: "/graphql", Tag: "graphql", Summary: "Run a GraphQL query", Query: graphqlParams, Response: anyDocument},
+	{Method: "POST", Path: "/graphql", Tag: "graphql", Summary: "Run a GraphQL query or mutation; API keys need the write scope for mutations", Request: graphqlRequest, Response: anyDocument},
+
+	{Method: "GET", Path: "/tenants", Tag: "tenants", Summary: "List tenants (platform admin)", Response: []models.Tenant{}},
+	{Method: "POST", Path: "/tenants", Tag: "tenants", Summary: "Create a tenant (platform admin)", Request: models.TenantRequest{}, Status: http.StatusCreated, Response: models.Tenant{}},
+	{Method: "GET", Path: "/tenants/{id}", Tag: "tenants", Summary: "Get a tenant (platform admin)", Response: models.Tenant{}},
+	{Method: "PUT", Path: "/tenants/{id}", Tag: "tenants", Summary: "Rename a tenant (platform admin)", Request: models.TenantRequest{}, Response: models.Tenant{}},
+	{Method: "DELETE", Path: "/tenants/{id}", Tag: "tenants", Summary: "Delete an empty tenant (platform admin)", Response: models.Tenant{}},
+
+	{Method: "GET", Path: "/api-keys", Tag: "api-keys", Summary: "List the API keys of the tenant, revoked ones included (admin)", Response: []models.APIKey{}},
+	{Method: "POST", Path: "/api-keys", Tag: "api-keys", Summary: "Create an API key; the response is the only one that carries the key (admin)", Request: models.APIKeyRequest{}, Status: http.StatusCreated, Response: models.APIKey{}},
+	{Method: "GET", Path: "/api-keys/{id}", Tag: "api-keys", Summary: "Get an API key (admin)", Response: models.APIKey{}},
+	{Method: "DELETE", Path: "/api-keys/{id}", Tag: "api-keys", Summary: "Revoke an API key (admin)", Response: models.APIKey{}},
+}
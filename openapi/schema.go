@@ -0,0 +1,95 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaRef returns the schema for t, registering named structs under
+// components/schemas and referencing them by name.
+func (d *builder) schemaRef(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case rawType:
+		// Embedded JSON documents may hold any value.
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := d.schemaRef(t.Elem())
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": d.schemaRef(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": d.schemaRef(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		if _, ok := d.schemas[t.Name()]; !ok {
+			// Reserve the name first so recursive types terminate.
+			d.schemas[t.Name()] = nil
+			d.schemas[t.Name()] = d.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema follows encoding/json: exported fields, json tag names,
+// "-" skipped and embedded structs flattened.
+func (d *builder) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	d.addFields(t, properties)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func (d *builder) addFields(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			d.addFields(field.Type, properties)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = d.schemaRef(field.Type)
+	}
+}
@@ -0,0 +1,82 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// KafkaSink produces every message to a topic through a Kafka REST proxy
+// (Confluent REST Proxy or the Redpanda HTTP proxy). The message id is
+// the record key, so consumers can drop duplicates.
+type KafkaSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewKafkaSink(restUrl string, topic string) (*KafkaSink, error) {
+	if restUrl == "" {
+		return nil, errors.New("KAFKA_REST_URL is required for the kafka sink")
+	}
+	if topic == "" {
+		topic = "carm.events"
+	}
+	return &KafkaSink{
+		url:    strings.TrimSuffix(restUrl, "/") + "/topics/" + url.PathEscape(topic),
+		client: &http.Client{Timeout: sinkTimeout},
+	}, nil
+}
+
+type kafkaRecord struct {
+	Key   string  `json:"key"`
+	Value Message `json:"value"`
+}
+
+type kafkaResponse struct {
+	Offsets []struct {
+		ErrorCode *int    `json:"error_code"`
+		Error     *string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (s *KafkaSink) Send(ctx context.Context, message Message) error {
+	body, err := json.Marshal(map[string][]kafkaRecord{
+		"records": {{Key: message.ID.String(), Value: message}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("kafka rest proxy responded with status %d", res.StatusCode)
+	}
+	var produced kafkaResponse
+	if err := json.NewDecoder(res.Body).Decode(&produced); err != nil {
+		return err
+	}
+	for _, offset := range produced.Offsets {
+		if offset.Error != nil || offset.ErrorCode != nil {
+			message := "unknown error"
+			if offset.Error != nil {
+				message = *offset.Error
+			}
+			return errors.New("kafka: " + message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// notifyChannel is the channel Add announces new messages on.
+	notifyChannel = "outbox"
+
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+	// listenerPing finds out about dead connections, which the listener
+	// would otherwise only notice once it sends something.
+	listenerPing = time.Minute
+)
+
+// Listener hands every outbox message to a sink that lives in this
+// process only, such as the event broker. Such a sink must not go through
+// the Relay: it would record its progress in sent_sinks for all instances,
+// and only the instance that claimed a message would see it. Listener
+// instead waits for the notification Add sends when the message commits,
+// which reaches every instance, and keeps no progress at all. Messages
+// written while its connection is down are missed.
+type Listener struct {
+	db   *sql.DB
+	dsn  string
+	sink Sink
+}
+
+// NewListener listens on a connection of its own to the database at dsn
+// and reads the messages through db.
+func NewListener(db *sql.DB, dsn string, sink Sink) *Listener {
+	return &Listener{db: db, dsn: dsn, sink: sink}
+}
+
+// Run hands messages to the sink until ctx is cancelled.
+func (l *Listener) Run(ctx context.Context) {
+	listener := pq.NewListener(l.dsn, minReconnectInterval, maxReconnectInterval, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logging.FromContext(ctx).Error("Error listening for outbox messages", "error", err)
+		}
+	})
+	defer listener.Close()
+	// Listen blocks until the connection is up; closing the listener
+	// stops the wait.
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+	if err := listener.Listen(notifyChannel); err != nil {
+		if ctx.Err() == nil {
+			logging.FromContext(ctx).Error("Error listening for outbox messages", "error", err)
+		}
+		return
+	}
+
+	ticker := time.NewTicker(listenerPing)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-listener.Notify:
+			// A nil notification follows a reconnect.
+			if notification != nil {
+				l.send(ctx, notification.Extra)
+			}
+		case <-ticker.C:
+			go listener.Ping()
+		}
+	}
+}
+
+func (l *Listener) send(ctx context.Context, id string) {
+	tracer := otel.Tracer("OutboxListener")
+	ctx, span := tracer.Start(ctx, "Send-Outbox")
+	defer span.End()
+
+	row := l.db.QueryRowContext(ctx, "SELECT id, tenant_id, entity, action, brand, payload, created_at FROM outbox WHERE id = $1", id)
+	message, err := scanMessage(row)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error reading outbox message", "message_id", id, "error", err)
+		return
+	}
+	if err := l.sink.Send(ctx, message); err != nil {
+		logging.FromContext(ctx).Error("Error publishing outbox message", "message_id", id, "error", err)
+	}
+}
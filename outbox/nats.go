@@ -0,0 +1,156 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSSink publishes every message to "<subject>.<type>" over the NATS
+// client protocol, e.g. carm.events.car.created. This is a core NATS
+// publish: the server confirms it received the message, not that anyone
+// stored it, so subscribers that are away miss it. The message id goes
+// into the Nats-Msg-Id header for consumers that drop duplicates.
+type NATSSink struct {
+	addr    string
+	user    string
+	pass    string
+	subject string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func NewNATSSink(natsUrl string, subject string) (*NATSSink, error) {
+	if natsUrl == "" {
+		natsUrl = "nats://localhost:4222"
+	}
+	if subject == "" {
+		subject = "carm.events"
+	}
+	parsed, err := url.Parse(natsUrl)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid NATS_URL %q", natsUrl)
+	}
+	sink := &NATSSink{addr: parsed.Host, subject: subject}
+	if parsed.Port() == "" {
+		sink.addr = net.JoinHostPort(parsed.Hostname(), "4222")
+	}
+	if parsed.User != nil {
+		sink.user = parsed.User.Username()
+		sink.pass, _ = parsed.User.Password()
+	}
+	return sink, nil
+}
+
+func (s *NATSSink) Send(ctx context.Context, message Message) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.send(ctx, message, payload); err != nil {
+		// Start over with a fresh connection on the next message.
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *NATSSink) send(ctx context.Context, message Message, payload []byte) error {
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+	deadline := time.Now().Add(sinkTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	s.conn.SetDeadline(deadline)
+
+	header := "NATS/1.0\r\nNats-Msg-Id: " + message.ID.String() + "\r\n\r\n"
+	subject := s.subject + "." + message.Type
+	frame := fmt.Sprintf("HPUB %s %d %d\r\n%s%s\r\nPING\r\n", subject, len(header), len(header)+len(payload), header, payload)
+	if _, err := s.conn.Write([]byte(frame)); err != nil {
+		return err
+	}
+	// The server handles commands in order, so the PONG confirms that it
+	// received the publish.
+	return s.awaitPong()
+}
+
+func (s *NATSSink) connect(ctx context.Context) error {
+	var dialer net.Dialer
+	dialCtx, cancel := context.WithTimeout(ctx, sinkTimeout)
+	defer cancel()
+	conn, err := dialer.DialContext(dialCtx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+	conn.SetDeadline(time.Now().Add(sinkTimeout))
+
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected greeting from nats: %q", strings.TrimSpace(line))
+	}
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"headers":  true,
+		"name":     "carm-outbox",
+		"lang":     "go",
+		"version":  "1.0.0",
+	}
+	if s.user != "" && s.pass == "" {
+		options["auth_token"] = s.user
+	} else if s.user != "" {
+		options["user"] = s.user
+		options["pass"] = s.pass
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+	return s.awaitPong()
+}
+
+func (s *NATSSink) awaitPong() error {
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("nats: " + strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Akmyrat17/carm/events"
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	pollInterval = time.Second
+	batchSize    = 100
+	// retention is how long published messages are kept before they are
+	// cleaned up.
+	retention      = 7 * 24 * time.Hour
+	cleanupEvery   = time.Hour
+	maxErrorLength = 1000
+)
+
+// Message is a domain event as stored in the outbox table. ID doubles as
+// the deduplication key: a message may reach a sink more than once, but
+// always with the same ID.
+type Message struct {
+	ID        uuid.UUID       `json:"id"`
+	TenantID  uuid.UUID       `json:"tenant_id"`
+	Type      string          `json:"type"`
+	Entity    string          `json:"entity"`
+	Action    string          `json:"action"`
+	Brand     string          `json:"brand,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"time"`
+}
+
+// Event converts the message back into the event it was written from.
+func (m Message) Event() events.Event {
+	return events.Event{
+		Key:    m.ID.String(),
+		Entity: m.Entity,
+		Action: m.Action,
+		Brand:  m.Brand,
+		Data:   m.Data,
+		Time:   m.CreatedAt,
+	}
+}
+
+// Add writes the event to the outbox within tx, so that it is published
+// if and only if the mutation in tx commits. The notification for the
+// Listeners is sent on commit as well.
+func Add(ctx context.Context, tx *sql.Tx, event events.Event) error {
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	id := uuid.New()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO outbox (id, tenant_id, entity, action, brand, payload, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		id, tenantId, event.Entity, event.Action, event.Brand, data, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", notifyChannel, id.String())
+	return err
+}
+
+// Relay publishes outbox messages to its sinks in the order they were
+// written. Every sink keeps its own progress in the sent_sinks column, so
+// a sink that fails only holds back its own messages: it gets the message
+// it rejected again on the next poll, which makes delivery at-least-once.
+// A message is marked published once every sink accepted it. Sinks that
+// live in one process only belong on a Listener instead.
+type Relay struct {
+	db    *sql.DB
+	sinks map[string]Sink
+}
+
+// NewRelay relays to sinks by name. The names are stored with the
+// messages, so they must stay the same across restarts.
+func NewRelay(db *sql.DB, sinks map[string]Sink) *Relay {
+	return &Relay{db: db, sinks: sinks}
+}
+
+// Run relays messages until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	lastCleanup := time.Time{}
+	for {
+		for name, sink := range r.sinks {
+			for {
+				relayed, err := r.relayBatch(ctx, name, sink)
+				if err != nil {
+					logging.FromContext(ctx).Error("Error relaying outbox messages", "sink", name, "error", err)
+					break
+				}
+				if relayed < batchSize {
+					break
+				}
+			}
+		}
+		if err := r.markPublished(ctx); err != nil {
+			logging.FromContext(ctx).Error("Error marking outbox messages published", "error", err)
+		}
+		if time.Since(lastCleanup) > cleanupEvery {
+			r.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayBatch locks the oldest messages the sink has not accepted yet, so
+// that several instances can run a relay side by side, and sends them. It
+// stops at the first message the sink rejects to keep the order.
+func (r *Relay) relayBatch(ctx context.Context, name string, sink Sink) (int, error) {
+	tracer := otel.Tracer("OutboxRelay")
+	ctx, span := tracer.Start(ctx, "RelayBatch-Outbox")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, tenant_id, entity, action, brand, payload, created_at FROM outbox
+		WHERE published_at IS NULL AND NOT ($2 = ANY(sent_sinks)) ORDER BY created_at, id LIMIT $1 FOR UPDATE SKIP LOCKED`, batchSize, name)
+	if err != nil {
+		return 0, err
+	}
+	var messages []Message
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		messages = append(messages, message)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	relayed := 0
+	for _, message := range messages {
+		if err := sink.Send(ctx, message); err != nil {
+			errText := name + ": " + err.Error()
+			if len(errText) > maxErrorLength {
+				errText = errText[:maxErrorLength]
+			}
+			logging.FromContext(ctx).Error("Error publishing outbox message", "sink", name, "message_id", message.ID, "error", err)
+			if _, err := tx.ExecContext(ctx, "UPDATE outbox SET attempts = attempts + 1, last_error = $1 WHERE id = $2", errText, message.ID); err != nil {
+				return relayed, err
+			}
+			break
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE outbox SET sent_sinks = array_append(sent_sinks, $1), attempts = attempts + 1 WHERE id = $2", name, message.ID); err != nil {
+			return relayed, err
+		}
+		relayed++
+	}
+	if err := tx.Commit(); err != nil {
+		return relayed, err
+	}
+	return relayed, nil
+}
+
+// markPublished marks the messages every sink has accepted. Messages that
+// only waited for a sink that is no longer configured count as well.
+func (r *Relay) markPublished(ctx context.Context) error {
+	names := make([]string, 0, len(r.sinks))
+	for name := range r.sinks {
+		names = append(names, name)
+	}
+	_, err := r.db.ExecContext(ctx, "UPDATE outbox SET published_at = $1, last_error = '' WHERE published_at IS NULL AND sent_sinks @> $2",
+		time.Now(), pq.Array(names))
+	return err
+}
+
+// scanMessage reads the id, tenant_id, entity, action, brand, payload and
+// created_at columns of an outbox row.
+func scanMessage(row interface{ Scan(dest ...any) error }) (Message, error) {
+	var message Message
+	err := row.Scan(&message.ID, &message.TenantID, &message.Entity, &message.Action, &message.Brand, &message.Data, &message.CreatedAt)
+	if err != nil {
+		return message, err
+	}
+	message.Type = message.Entity + "." + message.Action
+	return message, nil
+}
+
+func (r *Relay) cleanup(ctx context.Context) {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM outbox WHERE published_at < $1", time.Now().Add(-retention))
+	if err != nil {
+		logging.FromContext(ctx).Error("Error cleaning up outbox", "error", err)
+	}
+}
@@ -0,0 +1,132 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Akmyrat17/carm/events"
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/tenant"
+)
+
+const sinkTimeout = 10 * time.Second
+
+// Sink receives relayed messages. Send must only return nil once the
+// message is safely handed over; sinks should use Message.ID to drop
+// duplicates.
+type Sink interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// EventSink hands messages as events to an in-process consumer, with the
+// tenant of the message in ctx.
+type EventSink func(ctx context.Context, event events.Event) error
+
+func (s EventSink) Send(ctx context.Context, message Message) error {
+	return s(tenant.NewContext(ctx, message.TenantID), message.Event())
+}
+
+// PublisherSink adapts a publisher that cannot fail, such as the event
+// broker.
+func PublisherSink(publisher events.Publisher) Sink {
+	return EventSink(func(ctx context.Context, event events.Event) error {
+		publisher.Publish(ctx, event)
+		return nil
+	})
+}
+
+// LogSink writes every message to the log.
+type LogSink struct{}
+
+func (LogSink) Send(ctx context.Context, message Message) error {
+	logging.FromContext(ctx).Info("outbox", "type", message.Type, "message_id", message.ID, "tenant_id", message.TenantID, "data", string(message.Data))
+	return nil
+}
+
+// HTTPSink posts every message as JSON to a URL, with the message id in
+// the Idempotency-Key header.
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewHTTPSink(url string) (*HTTPSink, error) {
+	if url == "" {
+		return nil, errors.New("OUTBOX_HTTP_URL is required for the http sink")
+	}
+	return &HTTPSink{URL: url, Client: &http.Client{Timeout: sinkTimeout}}, nil
+}
+
+func (s *HTTPSink) Send(ctx context.Context, message Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", message.ID.String())
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("http sink responded with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// SinkConfig lists the external sinks and their settings.
+type SinkConfig struct {
+	// Sinks holds "log", "http", "nats" and "kafka".
+	Sinks       []string
+	HTTPURL     string
+	NATSURL     string
+	NATSSubject string
+	KafkaURL    string
+	KafkaTopic  string
+}
+
+// NewSinks builds the external sinks listed in cfg.Sinks, by name.
+func NewSinks(cfg SinkConfig) (map[string]Sink, error) {
+	sinks := map[string]Sink{}
+	for _, name := range cfg.Sinks {
+		switch name = strings.TrimSpace(name); name {
+		case "":
+		case "log":
+			sinks[name] = LogSink{}
+		case "http":
+			sink, err := NewHTTPSink(cfg.HTTPURL)
+			if err != nil {
+				return nil, err
+			}
+			sinks[name] = sink
+		case "nats":
+			sink, err := NewNATSSink(cfg.NATSURL, cfg.NATSSubject)
+			if err != nil {
+				return nil, err
+			}
+			sinks[name] = sink
+		case "kafka":
+			sink, err := NewKafkaSink(cfg.KafkaURL, cfg.KafkaTopic)
+			if err != nil {
+				return nil, err
+			}
+			sinks[name] = sink
+		default:
+			return nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+	return sinks, nil
+}
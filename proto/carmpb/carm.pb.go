@@ -0,0 +1,1057 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: carm.proto
+
+package carmpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Engine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Displacement  int64                  `protobuf:"varint,2,opt,name=displacement,proto3" json:"displacement,omitempty"`
+	NoOfCylinders int64                  `protobuf:"varint,3,opt,name=no_of_cylinders,json=noOfCylinders,proto3" json:"no_of_cylinders,omitempty"`
+	CarRange      int64                  `protobuf:"varint,4,opt,name=car_range,json=carRange,proto3" json:"car_range,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Engine) Reset() {
+	*x = Engine{}
+	mi := &file_carm_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Engine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Engine) ProtoMessage() {}
+
+func (x *Engine) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Engine.ProtoReflect.Descriptor instead.
+func (*Engine) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Engine) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Engine) GetDisplacement() int64 {
+	if x != nil {
+		return x.Displacement
+	}
+	return 0
+}
+
+func (x *Engine) GetNoOfCylinders() int64 {
+	if x != nil {
+		return x.NoOfCylinders
+	}
+	return 0
+}
+
+func (x *Engine) GetCarRange() int64 {
+	if x != nil {
+		return x.CarRange
+	}
+	return 0
+}
+
+func (x *Engine) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Engine) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type EngineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Displacement  int64                  `protobuf:"varint,1,opt,name=displacement,proto3" json:"displacement,omitempty"`
+	NoOfCylinders int64                  `protobuf:"varint,2,opt,name=no_of_cylinders,json=noOfCylinders,proto3" json:"no_of_cylinders,omitempty"`
+	CarRange      int64                  `protobuf:"varint,3,opt,name=car_range,json=carRange,proto3" json:"car_range,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EngineRequest) Reset() {
+	*x = EngineRequest{}
+	mi := &file_carm_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EngineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EngineRequest) ProtoMessage() {}
+
+func (x *EngineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EngineRequest.ProtoReflect.Descriptor instead.
+func (*EngineRequest) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EngineRequest) GetDisplacement() int64 {
+	if x != nil {
+		return x.Displacement
+	}
+	return 0
+}
+
+func (x *EngineRequest) GetNoOfCylinders() int64 {
+	if x != nil {
+		return x.NoOfCylinders
+	}
+	return 0
+}
+
+func (x *EngineRequest) GetCarRange() int64 {
+	if x != nil {
+		return x.CarRange
+	}
+	return 0
+}
+
+type Car struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Year          string                 `protobuf:"bytes,3,opt,name=year,proto3" json:"year,omitempty"`
+	FuelType      string                 `protobuf:"bytes,4,opt,name=fuel_type,json=fuelType,proto3" json:"fuel_type,omitempty"`
+	Price         float64                `protobuf:"fixed64,5,opt,name=price,proto3" json:"price,omitempty"`
+	Engine        *Engine                `protobuf:"bytes,6,opt,name=engine,proto3" json:"engine,omitempty"`
+	Brand         string                 `protobuf:"bytes,7,opt,name=brand,proto3" json:"brand,omitempty"`
+	Mileage       int64                  `protobuf:"varint,8,opt,name=mileage,proto3" json:"mileage,omitempty"`
+	LocationId    *string                `protobuf:"bytes,9,opt,name=location_id,json=locationId,proto3,oneof" json:"location_id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Car) Reset() {
+	*x = Car{}
+	mi := &file_carm_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Car) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Car) ProtoMessage() {}
+
+func (x *Car) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Car.ProtoReflect.Descriptor instead.
+func (*Car) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Car) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Car) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Car) GetYear() string {
+	if x != nil {
+		return x.Year
+	}
+	return ""
+}
+
+func (x *Car) GetFuelType() string {
+	if x != nil {
+		return x.FuelType
+	}
+	return ""
+}
+
+func (x *Car) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Car) GetEngine() *Engine {
+	if x != nil {
+		return x.Engine
+	}
+	return nil
+}
+
+func (x *Car) GetBrand() string {
+	if x != nil {
+		return x.Brand
+	}
+	return ""
+}
+
+func (x *Car) GetMileage() int64 {
+	if x != nil {
+		return x.Mileage
+	}
+	return 0
+}
+
+func (x *Car) GetLocationId() string {
+	if x != nil && x.LocationId != nil {
+		return *x.LocationId
+	}
+	return ""
+}
+
+func (x *Car) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Car) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CarRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Year          string                 `protobuf:"bytes,2,opt,name=year,proto3" json:"year,omitempty"`
+	FuelType      string                 `protobuf:"bytes,3,opt,name=fuel_type,json=fuelType,proto3" json:"fuel_type,omitempty"`
+	Brand         string                 `protobuf:"bytes,4,opt,name=brand,proto3" json:"brand,omitempty"`
+	Price         float64                `protobuf:"fixed64,5,opt,name=price,proto3" json:"price,omitempty"`
+	Engine        *Engine                `protobuf:"bytes,6,opt,name=engine,proto3" json:"engine,omitempty"`
+	LocationId    *string                `protobuf:"bytes,7,opt,name=location_id,json=locationId,proto3,oneof" json:"location_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CarRequest) Reset() {
+	*x = CarRequest{}
+	mi := &file_carm_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CarRequest) ProtoMessage() {}
+
+func (x *CarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CarRequest.ProtoReflect.Descriptor instead.
+func (*CarRequest) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CarRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CarRequest) GetYear() string {
+	if x != nil {
+		return x.Year
+	}
+	return ""
+}
+
+func (x *CarRequest) GetFuelType() string {
+	if x != nil {
+		return x.FuelType
+	}
+	return ""
+}
+
+func (x *CarRequest) GetBrand() string {
+	if x != nil {
+		return x.Brand
+	}
+	return ""
+}
+
+func (x *CarRequest) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *CarRequest) GetEngine() *Engine {
+	if x != nil {
+		return x.Engine
+	}
+	return nil
+}
+
+func (x *CarRequest) GetLocationId() string {
+	if x != nil && x.LocationId != nil {
+		return *x.LocationId
+	}
+	return ""
+}
+
+type GetCarRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCarRequest) Reset() {
+	*x = GetCarRequest{}
+	mi := &file_carm_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCarRequest) ProtoMessage() {}
+
+func (x *GetCarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCarRequest.ProtoReflect.Descriptor instead.
+func (*GetCarRequest) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetCarRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListCarsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Brand         string                 `protobuf:"bytes,1,opt,name=brand,proto3" json:"brand,omitempty"`
+	IsEngine      bool                   `protobuf:"varint,2,opt,name=is_engine,json=isEngine,proto3" json:"is_engine,omitempty"`
+	MinMileage    *int64                 `protobuf:"varint,3,opt,name=min_mileage,json=minMileage,proto3,oneof" json:"min_mileage,omitempty"`
+	MaxMileage    *int64                 `protobuf:"varint,4,opt,name=max_mileage,json=maxMileage,proto3,oneof" json:"max_mileage,omitempty"`
+	LocationId    *string                `protobuf:"bytes,5,opt,name=location_id,json=locationId,proto3,oneof" json:"location_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCarsRequest) Reset() {
+	*x = ListCarsRequest{}
+	mi := &file_carm_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCarsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCarsRequest) ProtoMessage() {}
+
+func (x *ListCarsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCarsRequest.ProtoReflect.Descriptor instead.
+func (*ListCarsRequest) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListCarsRequest) GetBrand() string {
+	if x != nil {
+		return x.Brand
+	}
+	return ""
+}
+
+func (x *ListCarsRequest) GetIsEngine() bool {
+	if x != nil {
+		return x.IsEngine
+	}
+	return false
+}
+
+func (x *ListCarsRequest) GetMinMileage() int64 {
+	if x != nil && x.MinMileage != nil {
+		return *x.MinMileage
+	}
+	return 0
+}
+
+func (x *ListCarsRequest) GetMaxMileage() int64 {
+	if x != nil && x.MaxMileage != nil {
+		return *x.MaxMileage
+	}
+	return 0
+}
+
+func (x *ListCarsRequest) GetLocationId() string {
+	if x != nil && x.LocationId != nil {
+		return *x.LocationId
+	}
+	return ""
+}
+
+type ListCarsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cars          []*Car                 `protobuf:"bytes,1,rep,name=cars,proto3" json:"cars,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCarsResponse) Reset() {
+	*x = ListCarsResponse{}
+	mi := &file_carm_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCarsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCarsResponse) ProtoMessage() {}
+
+func (x *ListCarsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCarsResponse.ProtoReflect.Descriptor instead.
+func (*ListCarsResponse) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListCarsResponse) GetCars() []*Car {
+	if x != nil {
+		return x.Cars
+	}
+	return nil
+}
+
+type CreateCarRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Car           *CarRequest            `protobuf:"bytes,1,opt,name=car,proto3" json:"car,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCarRequest) Reset() {
+	*x = CreateCarRequest{}
+	mi := &file_carm_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCarRequest) ProtoMessage() {}
+
+func (x *CreateCarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCarRequest.ProtoReflect.Descriptor instead.
+func (*CreateCarRequest) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateCarRequest) GetCar() *CarRequest {
+	if x != nil {
+		return x.Car
+	}
+	return nil
+}
+
+type UpdateCarRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Car           *CarRequest            `protobuf:"bytes,2,opt,name=car,proto3" json:"car,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCarRequest) Reset() {
+	*x = UpdateCarRequest{}
+	mi := &file_carm_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCarRequest) ProtoMessage() {}
+
+func (x *UpdateCarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCarRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCarRequest) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateCarRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateCarRequest) GetCar() *CarRequest {
+	if x != nil {
+		return x.Car
+	}
+	return nil
+}
+
+type DeleteCarRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCarRequest) Reset() {
+	*x = DeleteCarRequest{}
+	mi := &file_carm_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCarRequest) ProtoMessage() {}
+
+func (x *DeleteCarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCarRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCarRequest) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteCarRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetEngineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEngineRequest) Reset() {
+	*x = GetEngineRequest{}
+	mi := &file_carm_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEngineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEngineRequest) ProtoMessage() {}
+
+func (x *GetEngineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEngineRequest.ProtoReflect.Descriptor instead.
+func (*GetEngineRequest) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetEngineRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CreateEngineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Engine        *EngineRequest         `protobuf:"bytes,1,opt,name=engine,proto3" json:"engine,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateEngineRequest) Reset() {
+	*x = CreateEngineRequest{}
+	mi := &file_carm_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateEngineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateEngineRequest) ProtoMessage() {}
+
+func (x *CreateEngineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateEngineRequest.ProtoReflect.Descriptor instead.
+func (*CreateEngineRequest) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CreateEngineRequest) GetEngine() *EngineRequest {
+	if x != nil {
+		return x.Engine
+	}
+	return nil
+}
+
+type UpdateEngineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Engine        *EngineRequest         `protobuf:"bytes,2,opt,name=engine,proto3" json:"engine,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateEngineRequest) Reset() {
+	*x = UpdateEngineRequest{}
+	mi := &file_carm_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateEngineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateEngineRequest) ProtoMessage() {}
+
+func (x *UpdateEngineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateEngineRequest.ProtoReflect.Descriptor instead.
+func (*UpdateEngineRequest) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UpdateEngineRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateEngineRequest) GetEngine() *EngineRequest {
+	if x != nil {
+		return x.Engine
+	}
+	return nil
+}
+
+type DeleteEngineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteEngineRequest) Reset() {
+	*x = DeleteEngineRequest{}
+	mi := &file_carm_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteEngineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteEngineRequest) ProtoMessage() {}
+
+func (x *DeleteEngineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_carm_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteEngineRequest.ProtoReflect.Descriptor instead.
+func (*DeleteEngineRequest) Descriptor() ([]byte, []int) {
+	return file_carm_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DeleteEngineRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+var File_carm_proto protoreflect.FileDescriptor
+
+const file_carm_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"carm.proto\x12\acarm.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xf7\x01\n" +
+	"\x06Engine\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\"\n" +
+	"\fdisplacement\x18\x02 \x01(\x03R\fdisplacement\x12&\n" +
+	"\x0fno_of_cylinders\x18\x03 \x01(\x03R\rnoOfCylinders\x12\x1b\n" +
+	"\tcar_range\x18\x04 \x01(\x03R\bcarRange\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"x\n" +
+	"\rEngineRequest\x12\"\n" +
+	"\fdisplacement\x18\x01 \x01(\x03R\fdisplacement\x12&\n" +
+	"\x0fno_of_cylinders\x18\x02 \x01(\x03R\rnoOfCylinders\x12\x1b\n" +
+	"\tcar_range\x18\x03 \x01(\x03R\bcarRange\"\xf5\x02\n" +
+	"\x03Car\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04year\x18\x03 \x01(\tR\x04year\x12\x1b\n" +
+	"\tfuel_type\x18\x04 \x01(\tR\bfuelType\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\x01R\x05price\x12'\n" +
+	"\x06engine\x18\x06 \x01(\v2\x0f.carm.v1.EngineR\x06engine\x12\x14\n" +
+	"\x05brand\x18\a \x01(\tR\x05brand\x12\x18\n" +
+	"\amileage\x18\b \x01(\x03R\amileage\x12$\n" +
+	"\vlocation_id\x18\t \x01(\tH\x00R\n" +
+	"locationId\x88\x01\x01\x129\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\x0e\n" +
+	"\f_location_id\"\xdc\x01\n" +
+	"\n" +
+	"CarRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04year\x18\x02 \x01(\tR\x04year\x12\x1b\n" +
+	"\tfuel_type\x18\x03 \x01(\tR\bfuelType\x12\x14\n" +
+	"\x05brand\x18\x04 \x01(\tR\x05brand\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\x01R\x05price\x12'\n" +
+	"\x06engine\x18\x06 \x01(\v2\x0f.carm.v1.EngineR\x06engine\x12$\n" +
+	"\vlocation_id\x18\a \x01(\tH\x00R\n" +
+	"locationId\x88\x01\x01B\x0e\n" +
+	"\f_location_id\"\x1f\n" +
+	"\rGetCarRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xe6\x01\n" +
+	"\x0fListCarsRequest\x12\x14\n" +
+	"\x05brand\x18\x01 \x01(\tR\x05brand\x12\x1b\n" +
+	"\tis_engine\x18\x02 \x01(\bR\bisEngine\x12$\n" +
+	"\vmin_mileage\x18\x03 \x01(\x03H\x00R\n" +
+	"minMileage\x88\x01\x01\x12$\n" +
+	"\vmax_mileage\x18\x04 \x01(\x03H\x01R\n" +
+	"maxMileage\x88\x01\x01\x12$\n" +
+	"\vlocation_id\x18\x05 \x01(\tH\x02R\n" +
+	"locationId\x88\x01\x01B\x0e\n" +
+	"\f_min_mileageB\x0e\n" +
+	"\f_max_mileageB\x0e\n" +
+	"\f_location_id\"4\n" +
+	"\x10ListCarsResponse\x12 \n" +
+	"\x04cars\x18\x01 \x03(\v2\f.carm.v1.CarR\x04cars\"9\n" +
+	"\x10CreateCarRequest\x12%\n" +
+	"\x03car\x18\x01 \x01(\v2\x13.carm.v1.CarRequestR\x03car\"I\n" +
+	"\x10UpdateCarRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12%\n" +
+	"\x03car\x18\x02 \x01(\v2\x13.carm.v1.CarRequestR\x03car\"\"\n" +
+	"\x10DeleteCarRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\"\n" +
+	"\x10GetEngineRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"E\n" +
+	"\x13CreateEngineRequest\x12.\n" +
+	"\x06engine\x18\x01 \x01(\v2\x16.carm.v1.EngineRequestR\x06engine\"U\n" +
+	"\x13UpdateEngineRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12.\n" +
+	"\x06engine\x18\x02 \x01(\v2\x16.carm.v1.EngineRequestR\x06engine\"%\n" +
+	"\x13DeleteEngineRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id2\x9f\x02\n" +
+	"\n" +
+	"CarService\x12.\n" +
+	"\x06GetCar\x12\x16.carm.v1.GetCarRequest\x1a\f.carm.v1.Car\x12?\n" +
+	"\bListCars\x12\x18.carm.v1.ListCarsRequest\x1a\x19.carm.v1.ListCarsResponse\x124\n" +
+	"\tCreateCar\x12\x19.carm.v1.CreateCarRequest\x1a\f.carm.v1.Car\x124\n" +
+	"\tUpdateCar\x12\x19.carm.v1.UpdateCarRequest\x1a\f.carm.v1.Car\x124\n" +
+	"\tDeleteCar\x12\x19.carm.v1.DeleteCarRequest\x1a\f.carm.v1.Car2\x85\x02\n" +
+	"\rEngineService\x127\n" +
+	"\tGetEngine\x12\x19.carm.v1.GetEngineRequest\x1a\x0f.carm.v1.Engine\x12=\n" +
+	"\fCreateEngine\x12\x1c.carm.v1.CreateEngineRequest\x1a\x0f.carm.v1.Engine\x12=\n" +
+	"\fUpdateEngine\x12\x1c.carm.v1.UpdateEngineRequest\x1a\x0f.carm.v1.Engine\x12=\n" +
+	"\fDeleteEngine\x12\x1c.carm.v1.DeleteEngineRequest\x1a\x0f.carm.v1.EngineB(Z&github.com/Akmyrat17/carm/proto/carmpbb\x06proto3"
+
+var (
+	file_carm_proto_rawDescOnce sync.Once
+	file_carm_proto_rawDescData []byte
+)
+
+func file_carm_proto_rawDescGZIP() []byte {
+	file_carm_proto_rawDescOnce.Do(func() {
+		file_carm_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_carm_proto_rawDesc), len(file_carm_proto_rawDesc)))
+	})
+	return file_carm_proto_rawDescData
+}
+
+var file_carm_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_carm_proto_goTypes = []any{
+	(*Engine)(nil),                // 0: carm.v1.Engine
+	(*EngineRequest)(nil),         // 1: carm.v1.EngineRequest
+	(*Car)(nil),                   // 2: carm.v1.Car
+	(*CarRequest)(nil),            // 3: carm.v1.CarRequest
+	(*GetCarRequest)(nil),         // 4: carm.v1.GetCarRequest
+	(*ListCarsRequest)(nil),       // 5: carm.v1.ListCarsRequest
+	(*ListCarsResponse)(nil),      // 6: carm.v1.ListCarsResponse
+	(*CreateCarRequest)(nil),      // 7: carm.v1.CreateCarRequest
+	(*UpdateCarRequest)(nil),      // 8: carm.v1.UpdateCarRequest
+	(*DeleteCarRequest)(nil),      // 9: carm.v1.DeleteCarRequest
+	(*GetEngineRequest)(nil),      // 10: carm.v1.GetEngineRequest
+	(*CreateEngineRequest)(nil),   // 11: carm.v1.CreateEngineRequest
+	(*UpdateEngineRequest)(nil),   // 12: carm.v1.UpdateEngineRequest
+	(*DeleteEngineRequest)(nil),   // 13: carm.v1.DeleteEngineRequest
+	(*timestamppb.Timestamp)(nil), // 14: google.protobuf.Timestamp
+}
+var file_carm_proto_depIdxs = []int32{
+	14, // 0: carm.v1.Engine.created_at:type_name -> google.protobuf.Timestamp
+	14, // 1: carm.v1.Engine.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: carm.v1.Car.engine:type_name -> carm.v1.Engine
+	14, // 3: carm.v1.Car.created_at:type_name -> google.protobuf.Timestamp
+	14, // 4: carm.v1.Car.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 5: carm.v1.CarRequest.engine:type_name -> carm.v1.Engine
+	2,  // 6: carm.v1.ListCarsResponse.cars:type_name -> carm.v1.Car
+	3,  // 7: carm.v1.CreateCarRequest.car:type_name -> carm.v1.CarRequest
+	3,  // 8: carm.v1.UpdateCarRequest.car:type_name -> carm.v1.CarRequest
+	1,  // 9: carm.v1.CreateEngineRequest.engine:type_name -> carm.v1.EngineRequest
+	1,  // 10: carm.v1.UpdateEngineRequest.engine:type_name -> carm.v1.EngineRequest
+	4,  // 11: carm.v1.CarService.GetCar:input_type -> carm.v1.GetCarRequest
+	5,  // 12: carm.v1.CarService.ListCars:input_type -> carm.v1.ListCarsRequest
+	7,  // 13: carm.v1.CarService.CreateCar:input_type -> carm.v1.CreateCarRequest
+	8,  // 14: carm.v1.CarService.UpdateCar:input_type -> carm.v1.UpdateCarRequest
+	9,  // 15: carm.v1.CarService.DeleteCar:input_type -> carm.v1.DeleteCarRequest
+	10, // 16: carm.v1.EngineService.GetEngine:input_type -> carm.v1.GetEngineRequest
+	11, // 17: carm.v1.EngineService.CreateEngine:input_type -> carm.v1.CreateEngineRequest
+	12, // 18: carm.v1.EngineService.UpdateEngine:input_type -> carm.v1.UpdateEngineRequest
+	13, // 19: carm.v1.EngineService.DeleteEngine:input_type -> carm.v1.DeleteEngineRequest
+	2,  // 20: carm.v1.CarService.GetCar:output_type -> carm.v1.Car
+	6,  // 21: carm.v1.CarService.ListCars:output_type -> carm.v1.ListCarsResponse
+	2,  // 22: carm.v1.CarService.CreateCar:output_type -> carm.v1.Car
+	2,  // 23: carm.v1.CarService.UpdateCar:output_type -> carm.v1.Car
+	2,  // 24: carm.v1.CarService.DeleteCar:output_type -> carm.v1.Car
+	0,  // 25: carm.v1.EngineService.GetEngine:output_type -> carm.v1.Engine
+	0,  // 26: carm.v1.EngineService.CreateEngine:output_type -> carm.v1.Engine
+	0,  // 27: carm.v1.EngineService.UpdateEngine:output_type -> carm.v1.Engine
+	0,  // 28: carm.v1.EngineService.DeleteEngine:output_type -> carm.v1.Engine
+	20, // [20:29] is the sub-list for method output_type
+	11, // [11:20] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_carm_proto_init() }
+func file_carm_proto_init() {
+	if File_carm_proto != nil {
+		return
+	}
+	file_carm_proto_msgTypes[2].OneofWrappers = []any{}
+	file_carm_proto_msgTypes[3].OneofWrappers = []any{}
+	file_carm_proto_msgTypes[5].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_carm_proto_rawDesc), len(file_carm_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_carm_proto_goTypes,
+		DependencyIndexes: file_carm_proto_depIdxs,
+		MessageInfos:      file_carm_proto_msgTypes,
+	}.Build()
+	File_carm_proto = out.File
+	file_carm_proto_goTypes = nil
+	file_carm_proto_depIdxs = nil
+}
@@ -0,0 +1,4 @@
+// Package carmpb holds the Go code generated from proto/carm.proto.
+package carmpb
+
+//go:generate protoc --proto_path=.. --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative ../carm.proto
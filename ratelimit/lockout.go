@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Lockout slows down password guessing. Every account may fail to sign in
+// maxFailures times per window; once they are used up, sign-ins are
+// refused until a failure has aged out, which takes window/maxFailures. A
+// successful sign-in forgets the failures.
+type Lockout struct {
+	store  Store
+	policy Policy
+}
+
+// NewLockout returns a Lockout that keeps its counts in store. A
+// maxFailures of 0 turns it off.
+func NewLockout(store Store, maxFailures int, window time.Duration) *Lockout {
+	return &Lockout{
+		store:  store,
+		policy: Policy{Limit: maxFailures, Period: window, Burst: maxFailures},
+	}
+}
+
+// Check returns how long account has to wait before it may try to sign in
+// again, or 0 when it may try now.
+func (l *Lockout) Check(ctx context.Context, account string) (time.Duration, error) {
+	if l.policy.Unlimited() {
+		return 0, nil
+	}
+	result, err := l.store.Take(ctx, lockoutKey(account), l.policy, 0)
+	if err != nil {
+		return 0, err
+	}
+	return result.RetryAfter, nil
+}
+
+// Fail records a failed sign-in of account.
+func (l *Lockout) Fail(ctx context.Context, account string) error {
+	if l.policy.Unlimited() {
+		return nil
+	}
+	_, err := l.store.Take(ctx, lockoutKey(account), l.policy, 1)
+	return err
+}
+
+// Succeed forgets the failed sign-ins of account.
+func (l *Lockout) Succeed(ctx context.Context, account string) error {
+	if l.policy.Unlimited() {
+		return nil
+	}
+	return l.store.Reset(ctx, lockoutKey(account))
+}
+
+func lockoutKey(account string) string {
+	return "login:" + account
+}
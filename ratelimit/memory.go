@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// cleanupInterval is how often full buckets are removed.
+const cleanupInterval = time.Minute
+
+// Memory keeps the buckets of this instance only.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]memoryBucket
+}
+
+type memoryBucket struct {
+	bucket
+	// full is when the bucket has refilled and can be forgotten.
+	full time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]memoryBucket)}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, policy Policy, cost int) (Result, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, result := take(m.buckets[key].bucket, policy, cost, now)
+	m.buckets[key] = memoryBucket{bucket: b, full: now.Add(result.Reset)}
+	return result, nil
+}
+
+func (m *Memory) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.buckets, key)
+	return nil
+}
+
+// Run removes full buckets every minute until ctx is cancelled.
+func (m *Memory) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			for key, b := range m.buckets {
+				if !now.Before(b.full) {
+					delete(m.buckets, key)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"go.opentelemetry.io/otel"
+)
+
+// Postgres keeps the buckets in the rate_limit_bucket table, so that all
+// instances share them. Times are stored in UTC.
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Take(ctx context.Context, key string, policy Policy, cost int) (result Result, err error) {
+	tracer := otel.Tracer("RateLimitStore")
+	ctx, span := tracer.Start(ctx, "Take-Store")
+	defer span.End()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
+			}
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	// New keys get a row without an update time, which take treats as a
+	// full bucket, so that there is always a row to lock.
+	now := time.Now().UTC()
+	_, err = tx.ExecContext(ctx, "INSERT INTO rate_limit_bucket (key, tokens, updated_at, full_at) VALUES ($1, 0, NULL, $2) ON CONFLICT (key) DO NOTHING", key, now)
+	if err != nil {
+		return result, err
+	}
+	var b bucket
+	var updated sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT tokens, updated_at FROM rate_limit_bucket WHERE key = $1 FOR UPDATE", key).Scan(&b.tokens, &updated)
+	if err != nil {
+		return result, err
+	}
+	if updated.Valid {
+		b.updated = updated.Time
+	}
+	b, result = take(b, policy, cost, now)
+	_, err = tx.ExecContext(ctx, "UPDATE rate_limit_bucket SET tokens = $1, updated_at = $2, full_at = $3 WHERE key = $4", b.tokens, b.updated, now.Add(result.Reset), key)
+	return result, err
+}
+
+func (p *Postgres) Reset(ctx context.Context, key string) error {
+	tracer := otel.Tracer("RateLimitStore")
+	ctx, span := tracer.Start(ctx, "Reset-Store")
+	defer span.End()
+
+	_, err := p.db.ExecContext(ctx, "DELETE FROM rate_limit_bucket WHERE key = $1", key)
+	return err
+}
+
+// Run removes full buckets every minute until ctx is cancelled.
+func (p *Postgres) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.db.ExecContext(ctx, "DELETE FROM rate_limit_bucket WHERE full_at <= $1", time.Now().UTC()); err != nil {
+				logging.FromContext(ctx).Error("Error deleting full rate limit buckets", "error", err)
+			}
+		}
+	}
+}
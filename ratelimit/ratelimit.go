@@ -0,0 +1,182 @@
+// Package ratelimit counts requests in token buckets. A bucket holds up to
+// Policy.Burst tokens and refills at Policy.Limit tokens per Policy.Period;
+// every request takes a token and is refused while the bucket is empty.
+//
+// The buckets are kept in memory, in Postgres or in Redis. Only the last
+// two are shared between server instances.
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+	BackendRedis    = "redis"
+
+	// NoLimit is the policy text that turns limiting off.
+	NoLimit = "none"
+)
+
+// Policy is a token bucket: Limit requests per Period on average, with
+// bursts of up to Burst requests.
+type Policy struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// ParsePolicy reads a policy written as limit/period[:burst], such as
+// "100/1m" or "100/1m:20". The burst defaults to the limit. "none" returns
+// the zero policy, which allows everything.
+func ParsePolicy(value string) (Policy, error) {
+	value = strings.TrimSpace(value)
+	if value == NoLimit {
+		return Policy{}, nil
+	}
+	rate, burst, hasBurst := strings.Cut(value, ":")
+	limit, period, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("invalid rate limit %q, expected limit/period[:burst] or none", value)
+	}
+	var policy Policy
+	var err error
+	if policy.Limit, err = strconv.Atoi(limit); err != nil || policy.Limit <= 0 {
+		return Policy{}, fmt.Errorf("invalid limit in rate limit %q", value)
+	}
+	if policy.Period, err = time.ParseDuration(period); err != nil || policy.Period <= 0 {
+		return Policy{}, fmt.Errorf("invalid period in rate limit %q", value)
+	}
+	policy.Burst = policy.Limit
+	if hasBurst {
+		if policy.Burst, err = strconv.Atoi(burst); err != nil || policy.Burst <= 0 {
+			return Policy{}, fmt.Errorf("invalid burst in rate limit %q", value)
+		}
+	}
+	return policy, nil
+}
+
+// Unlimited reports whether the policy allows every request.
+func (p Policy) Unlimited() bool {
+	return p.Limit <= 0
+}
+
+func (p Policy) String() string {
+	if p.Unlimited() {
+		return NoLimit
+	}
+	if p.Burst == p.Limit {
+		return fmt.Sprintf("%d/%s", p.Limit, p.Period)
+	}
+	return fmt.Sprintf("%d/%s:%d", p.Limit, p.Period, p.Burst)
+}
+
+// rate is the refill rate in tokens per second.
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// Result is the state of a bucket after a request.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left.
+	Remaining int
+	// RetryAfter is how long a refused request has to wait for a token.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps the buckets. Take takes cost tokens from the bucket of key,
+// creating a full one for new keys. A cost of 0 only looks: the result
+// tells whether a request would be allowed. Reset forgets key, so that its
+// next bucket starts full.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy, cost int) (Result, error)
+	Reset(ctx context.Context, key string) error
+	// Run maintains the store, such as removing full buckets, until ctx
+	// is cancelled.
+	Run(ctx context.Context)
+}
+
+// Config selects the backend of New.
+type Config struct {
+	// Backend is "memory", the default, "postgres" or "redis".
+	Backend string
+	// RedisURL is a redis:// or rediss:// URL for the redis backend.
+	RedisURL string
+}
+
+// New builds the Store selected by cfg. db is used by the postgres
+// backend.
+func New(cfg Config, db *sql.DB) (Store, error) {
+	switch cfg.Backend {
+	case "", BackendMemory:
+		return NewMemory(), nil
+	case BackendPostgres:
+		return NewPostgres(db), nil
+	case BackendRedis:
+		options, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis URL: %w", err)
+		}
+		return NewRedis(redis.NewClient(options)), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.Backend)
+	}
+}
+
+// bucket is the stored state of a key.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills b for the time passed since its last update and takes cost
+// tokens if there are enough. A new bucket, with a zero update time, starts
+// full.
+func take(b bucket, policy Policy, cost int, now time.Time) (bucket, Result) {
+	burst := float64(policy.Burst)
+	tokens := burst
+	if !b.updated.IsZero() {
+		elapsed := max(now.Sub(b.updated).Seconds(), 0)
+		tokens = min(burst, b.tokens+elapsed*policy.rate())
+	}
+	allowed := tokens >= needed(cost)
+	if allowed {
+		tokens -= float64(cost)
+	}
+	return bucket{tokens: tokens, updated: now}, policy.result(tokens, cost, allowed)
+}
+
+// needed is the number of tokens a request must find; looking, with a
+// cost of 0, asks whether a request would be allowed.
+func needed(cost int) float64 {
+	return float64(max(cost, 1))
+}
+
+// result describes a bucket left with tokens after a take.
+func (p Policy) result(tokens float64, cost int, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(p.Burst) - tokens) / p.rate()),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((needed(cost) - tokens) / p.rate())
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
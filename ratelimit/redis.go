@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+)
+
+const redisKeyPrefix = "carm:ratelimit:"
+
+// takeScript is take in Lua, so that the bucket is read and written in one
+// step. It uses the clock of Redis, which all instances share, and lets
+// Redis expire the bucket once it has refilled.
+var takeScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local tokens = burst
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+if state[1] then
+	tokens = math.min(burst, tonumber(state[1]) + math.max(now - tonumber(state[2]), 0) * rate)
+end
+local allowed = 0
+if tokens >= math.max(cost, 1) then
+	tokens = tokens - cost
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.max(math.ceil((burst - tokens) / rate * 1000), 1))
+return {allowed, tostring(tokens)}
+`)
+
+// Redis keeps the buckets in Redis, or a server speaking its protocol such
+// as Valkey, so that all instances share them.
+type Redis struct {
+	client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+func (r *Redis) Take(ctx context.Context, key string, policy Policy, cost int) (Result, error) {
+	tracer := otel.Tracer("RateLimitStore")
+	ctx, span := tracer.Start(ctx, "Take-Store")
+	defer span.End()
+
+	reply, err := takeScript.Run(ctx, r.client, []string{redisKeyPrefix + key}, policy.Burst, policy.rate(), cost).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	allowed, _ := reply[0].(int64)
+	text, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Result{}, err
+	}
+	return policy.result(tokens, cost, allowed == 1), nil
+}
+
+func (r *Redis) Reset(ctx context.Context, key string) error {
+	tracer := otel.Tracer("RateLimitStore")
+	ctx, span := tracer.Start(ctx, "Reset-Store")
+	defer span.End()
+
+	return r.client.Del(ctx, redisKeyPrefix+key).Err()
+}
+
+// Run closes the client once ctx is cancelled; Redis expires the buckets
+// itself.
+func (r *Redis) Run(ctx context.Context) {
+	<-ctx.Done()
+	if err := r.client.Close(); err != nil {
+		logging.FromContext(ctx).Error("Error closing redis client", "error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net/http"
+
+	apiKeyHandler "github.com/Akmyrat17/carm/handler/apikey"
+	attachmentHandler "github.com/Akmyrat17/carm/handler/attachment"
+	carHandler "github.com/Akmyrat17/carm/handler/car"
+	engineHandler "github.com/Akmyrat17/carm/handler/engine"
+	eventsHandler "github.com/Akmyrat17/carm/handler/events"
+	locationHandler "github.com/Akmyrat17/carm/handler/location"
+	loginHandler "github.com/Akmyrat17/carm/handler/login"
+	odometerHandler "github.com/Akmyrat17/carm/handler/odometer"
+	serviceRecordHandler "github.com/Akmyrat17/carm/handler/servicerecord"
+	tenantHandler "github.com/Akmyrat17/carm/handler/tenant"
+	webhookHandler "github.com/Akmyrat17/carm/handler/webhook"
+	"github.com/Akmyrat17/carm/middleware"
+	"github.com/Akmyrat17/carm/openapi"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// routes holds what the HTTP API is served by.
+type routes struct {
+	auth        *middleware.Auth
+	rateLimit   *middleware.RateLimit
+	idempotency *middleware.Idempotency
+
+	login         *loginHandler.LoginHandler
+	cars          *carHandler.CarHandler
+	serviceRecord *serviceRecordHandler.ServiceRecordHandler
+	odometer      *odometerHandler.OdometerHandler
+	attachments   *attachmentHandler.AttachmentHandler
+	locations     *locationHandler.LocationHandler
+	engines       *engineHandler.EngineHandler
+	events        *eventsHandler.EventsHandler
+	webhooks      *webhookHandler.WebhookHandler
+	graphql       http.Handler
+	tenants       *tenantHandler.TenantHandler
+	apiKeys       *apiKeyHandler.APIKeyHandler
+}
+
+// apiDocument describes the routes registered by register.
+func apiDocument() map[string]interface{} {
+	return openapi.Document("carm", "1.0.0", openapi.Operations)
+}
+
+// register adds the API, metrics and documentation routes to router.
+// Every API route needs an operation in openapi.Operations, which
+// TestRoutesDocumented checks.
+func (h routes) register(router *mux.Router) {
+	router.Handle("/login", h.rateLimit.Middleware(http.HandlerFunc(h.login.Login))).Methods("POST")
+
+	protected := router.PathPrefix("/").Subrouter()
+	protected.Use(h.auth.Middleware)
+	protected.Use(h.rateLimit.Middleware)
+	// GraphQL checks the scope of the operation it runs rather than that
+	// of the method, as queries may be sent with POST as well.
+	protected.Handle("/graphql", h.graphql).Methods("GET", "POST")
+
+	api := protected.NewRoute().Subrouter()
+	api.Use(middleware.MethodScopes)
+	api.HandleFunc("/cars/{id}", h.cars.GetCarByID).Methods("GET")
+	api.Handle("/cars", h.idempotency.Middleware(http.HandlerFunc(h.cars.CreateCar))).Methods("POST")
+	api.HandleFunc("/cars", h.cars.GetCars).Methods("GET")
+	api.Handle("/cars/batch", h.idempotency.Middleware(http.HandlerFunc(h.cars.RunCarBatch))).Methods("POST")
+	api.HandleFunc("/cars/{id}", h.cars.UpdateCar).Methods("PUT")
+	api.HandleFunc("/cars/{id}", h.cars.DeleteCar).Methods("DELETE")
+
+	api.HandleFunc("/cars/{id}/service-records", h.serviceRecord.GetServiceRecords).Methods("GET")
+	api.HandleFunc("/cars/{id}/service-records", h.serviceRecord.CreateServiceRecord).Methods("POST")
+	api.HandleFunc("/cars/{id}/service-records/next-due", h.serviceRecord.GetNextServiceDue).Methods("GET")
+	api.HandleFunc("/cars/{id}/service-records/{recordId}", h.serviceRecord.GetServiceRecordById).Methods("GET")
+	api.HandleFunc("/cars/{id}/service-records/{recordId}", h.serviceRecord.UpdateServiceRecord).Methods("PUT")
+	api.HandleFunc("/cars/{id}/service-records/{recordId}", h.serviceRecord.DeleteServiceRecord).Methods("DELETE")
+
+	api.HandleFunc("/cars/{id}/odometer", h.odometer.GetOdometerReadings).Methods("GET")
+	api.HandleFunc("/cars/{id}/odometer", h.odometer.CreateOdometerReading).Methods("POST")
+
+	api.HandleFunc("/cars/{id}/attachments", h.attachments.GetAttachments).Methods("GET")
+	api.HandleFunc("/cars/{id}/attachments", h.attachments.UploadAttachment).Methods("POST")
+	api.HandleFunc("/cars/{id}/attachments/{attachmentId}", h.attachments.DownloadAttachment).Methods("GET")
+	api.HandleFunc("/cars/{id}/attachments/{attachmentId}/thumbnail", h.attachments.DownloadThumbnail).Methods("GET")
+	api.HandleFunc("/cars/{id}/attachments/{attachmentId}", h.attachments.DeleteAttachment).Methods("DELETE")
+
+	api.HandleFunc("/cars/{id}/transfers", h.locations.TransferCar).Methods("POST")
+	api.HandleFunc("/cars/{id}/movements", h.locations.GetCarMovements).Methods("GET")
+
+	api.HandleFunc("/locations", h.locations.GetLocations).Methods("GET")
+	api.HandleFunc("/locations", h.locations.CreateLocation).Methods("POST")
+	api.HandleFunc("/locations/counts", h.locations.GetLocationCounts).Methods("GET")
+	api.HandleFunc("/locations/{id}", h.locations.GetLocationById).Methods("GET")
+	api.HandleFunc("/locations/{id}", h.locations.UpdateLocation).Methods("PUT")
+	api.HandleFunc("/locations/{id}", h.locations.DeleteLocation).Methods("DELETE")
+	api.HandleFunc("/locations/{id}/cars", h.cars.GetCarsByLocation).Methods("GET")
+
+	api.HandleFunc("/engines", h.engines.GetEngines).Methods("GET")
+	api.HandleFunc("/engines/{id}", h.engines.GetEngineById).Methods("GET")
+	api.Handle("/engines", h.idempotency.Middleware(http.HandlerFunc(h.engines.CreateEngine))).Methods("POST")
+	api.HandleFunc("/engines/{id}", h.engines.UpdateEngine).Methods("PUT")
+	api.HandleFunc("/engines/{id}", h.engines.DeleteEngine).Methods("DELETE")
+
+	api.HandleFunc("/events", h.events.StreamEvents).Methods("GET")
+
+	admin := api.PathPrefix("/tenants").Subrouter()
+	admin.Use(middleware.PlatformAdminOnly)
+	admin.HandleFunc("", h.tenants.GetTenants).Methods("GET")
+	admin.HandleFunc("", h.tenants.CreateTenant).Methods("POST")
+	admin.HandleFunc("/{id}", h.tenants.GetTenantById).Methods("GET")
+	admin.HandleFunc("/{id}", h.tenants.UpdateTenant).Methods("PUT")
+	admin.HandleFunc("/{id}", h.tenants.DeleteTenant).Methods("DELETE")
+
+	webhooks := api.PathPrefix("/webhooks").Subrouter()
+	webhooks.Use(middleware.AdminOnly)
+	webhooks.HandleFunc("", h.webhooks.GetWebhooks).Methods("GET")
+	webhooks.HandleFunc("", h.webhooks.CreateWebhook).Methods("POST")
+	webhooks.HandleFunc("/{id}", h.webhooks.GetWebhookById).Methods("GET")
+	webhooks.HandleFunc("/{id}", h.webhooks.UpdateWebhook).Methods("PUT")
+	webhooks.HandleFunc("/{id}", h.webhooks.DeleteWebhook).Methods("DELETE")
+	webhooks.HandleFunc("/{id}/deliveries", h.webhooks.GetDeliveries).Methods("GET")
+
+	apiKeys := api.PathPrefix("/api-keys").Subrouter()
+	apiKeys.Use(middleware.AdminOnly)
+	apiKeys.HandleFunc("", h.apiKeys.GetAPIKeys).Methods("GET")
+	apiKeys.HandleFunc("", h.apiKeys.CreateAPIKey).Methods("POST")
+	apiKeys.HandleFunc("/{id}", h.apiKeys.GetAPIKeyById).Methods("GET")
+	apiKeys.HandleFunc("/{id}", h.apiKeys.RevokeAPIKey).Methods("DELETE")
+
+	router.Handle("/metrics", promhttp.Handler())
+
+	router.HandleFunc("/openapi.json", openapi.SpecHandler(apiDocument())).Methods("GET")
+	router.HandleFunc("/docs", openapi.DocsHandler).Methods("GET")
+}
@@ -0,0 +1,136 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// keyPrefix marks carm keys, so that leaked ones are easy to find.
+	keyPrefix = "carm_"
+	// shownLength is how much of a key is kept in the clear as its
+	// prefix.
+	shownLength = len(keyPrefix) + 8
+	// lastUsedResolution limits the writes of the last use to one per key
+	// and interval.
+	lastUsedResolution = time.Minute
+)
+
+type APIKeyService struct {
+	store store.APIKeyStoreInterface
+}
+
+func NewAPIKeyService(store store.APIKeyStoreInterface) *APIKeyService {
+	return &APIKeyService{store: store}
+}
+
+func (s APIKeyService) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	tracer := otel.Tracer("APIKeyService")
+	ctx, span := tracer.Start(ctx, "GetAPIKeys-Service")
+	defer span.End()
+
+	keys, err := s.store.GetAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return keys, err
+}
+
+func (s APIKeyService) GetAPIKeyById(ctx context.Context, id string) (models.APIKey, error) {
+	tracer := otel.Tracer("APIKeyService")
+	ctx, span := tracer.Start(ctx, "GetAPIKeyById-Service")
+	defer span.End()
+
+	key, err := s.store.GetAPIKeyById(ctx, id)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	return key, err
+}
+
+// CreateAPIKey generates the key; the response is the only place it is
+// ever shown.
+func (s APIKeyService) CreateAPIKey(ctx context.Context, keyReq *models.APIKeyRequest) (models.APIKey, error) {
+	tracer := otel.Tracer("APIKeyService")
+	ctx, span := tracer.Start(ctx, "CreateAPIKey-Service")
+	defer span.End()
+
+	if len(keyReq.Scopes) == 0 {
+		keyReq.Scopes = []string{models.APIKeyScopeRead}
+	}
+	if err := models.ValidateAPIKeyRequest(*keyReq); err != nil {
+		return models.APIKey{}, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return models.APIKey{}, err
+	}
+	plain := keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	key, err := s.store.CreateAPIKey(ctx, keyReq, plain[:shownLength], hashKey(plain))
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	key.Key = plain
+	return key, err
+}
+
+// RevokeAPIKey stops the key from being accepted; it stays listed.
+func (s APIKeyService) RevokeAPIKey(ctx context.Context, id string) (models.APIKey, error) {
+	tracer := otel.Tracer("APIKeyService")
+	ctx, span := tracer.Start(ctx, "RevokeAPIKey-Service")
+	defer span.End()
+
+	key, err := s.store.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	return key, err
+}
+
+// AuthenticateAPIKey returns the key with the given plain text, or a zero
+// APIKey when it is unknown or revoked. It records when the key was last
+// used, at most once per minute.
+func (s APIKeyService) AuthenticateAPIKey(ctx context.Context, plain string) (models.APIKey, error) {
+	tracer := otel.Tracer("APIKeyService")
+	ctx, span := tracer.Start(ctx, "AuthenticateAPIKey-Service")
+	defer span.End()
+
+	if !strings.HasPrefix(plain, keyPrefix) {
+		return models.APIKey{}, nil
+	}
+	key, err := s.store.GetAPIKeyByHash(ctx, hashKey(plain))
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	if key.ID == uuid.Nil || key.Revoked() {
+		return models.APIKey{}, nil
+	}
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		// A missed update only makes the last use look older.
+		if err := s.store.TouchAPIKey(ctx, key.ID, now); err != nil {
+			logging.FromContext(ctx).Error("Error recording api key use", "error", err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+// hashKey returns the hex SHA-256 of a key. Keys carry 256 random bits, so
+// unlike passwords they need no slow hash.
+func hashKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,160 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Akmyrat17/carm/blob"
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+// DefaultMaxSize is the upload limit used when ATTACHMENT_MAX_SIZE is unset.
+const DefaultMaxSize int64 = 10 << 20
+
+type AttachmentService struct {
+	store   store.AttachmentStoreInterface
+	blobs   blob.Storage
+	maxSize int64
+}
+
+func NewAttachmentService(store store.AttachmentStoreInterface, blobs blob.Storage, maxSize int64) *AttachmentService {
+	return &AttachmentService{store: store, blobs: blobs, maxSize: maxSize}
+}
+
+func (a AttachmentService) GetAttachments(ctx context.Context, carId string) ([]models.Attachment, error) {
+	tracer := otel.Tracer("AttachmentService")
+	ctx, span := tracer.Start(ctx, "GetAttachments-Service")
+	defer span.End()
+
+	attachments, err := a.store.GetAttachments(ctx, carId)
+	if err != nil {
+		return nil, err
+	}
+	return attachments, err
+}
+
+// UploadAttachment stores the file (and a thumbnail for images) in blob
+// storage before recording it, removing the blobs again if the record
+// cannot be written. The content type is sniffed from the file itself
+// rather than trusted from the client.
+func (a AttachmentService) UploadAttachment(ctx context.Context, carId string, upload *models.AttachmentUpload) (models.Attachment, error) {
+	tracer := otel.Tracer("AttachmentService")
+	ctx, span := tracer.Start(ctx, "UploadAttachment-Service")
+	defer span.End()
+
+	carUUID, err := uuid.Parse(carId)
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("%w: invalid car id: %v", models.ErrInvalidUpload, err)
+	}
+	upload.ContentType = strings.SplitN(http.DetectContentType(upload.Content), ";", 2)[0]
+	if err := models.ValidateAttachmentUpload(*upload, a.maxSize); err != nil {
+		return models.Attachment{}, err
+	}
+
+	id := uuid.New()
+	attachment := models.Attachment{
+		ID:          id,
+		CarID:       carUUID,
+		Kind:        upload.Kind,
+		FileName:    upload.FileName,
+		ContentType: upload.ContentType,
+		Size:        int64(len(upload.Content)),
+		StorageKey:  fmt.Sprintf("cars/%s/%s", carUUID, id),
+		CreatedAt:   time.Now(),
+	}
+	// The thumbnail is made first, so that oversized images are refused
+	// before anything is stored.
+	var thumbnail []byte
+	if strings.HasPrefix(attachment.ContentType, "image/") {
+		thumbnail, err = makeThumbnail(upload.Content)
+		if errors.Is(err, models.ErrInvalidUpload) {
+			return models.Attachment{}, err
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("Error generating thumbnail", "error", err)
+		}
+	}
+	if err := a.blobs.Put(ctx, attachment.StorageKey, bytes.NewReader(upload.Content), attachment.Size, attachment.ContentType); err != nil {
+		return models.Attachment{}, err
+	}
+	if thumbnail != nil {
+		thumbnailKey := attachment.StorageKey + "-thumbnail"
+		if err := a.blobs.Put(ctx, thumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err != nil {
+			a.DeleteBlobs(ctx, []models.Attachment{attachment})
+			return models.Attachment{}, err
+		}
+		attachment.ThumbnailKey = thumbnailKey
+	}
+
+	created, err := a.store.CreateAttachment(ctx, &attachment)
+	if err != nil {
+		a.DeleteBlobs(ctx, []models.Attachment{attachment})
+		return models.Attachment{}, err
+	}
+	return created, nil
+}
+
+func (a AttachmentService) OpenAttachment(ctx context.Context, carId string, id string, thumbnail bool) (models.Attachment, io.ReadCloser, error) {
+	tracer := otel.Tracer("AttachmentService")
+	ctx, span := tracer.Start(ctx, "OpenAttachment-Service")
+	defer span.End()
+
+	attachment, err := a.store.GetAttachmentById(ctx, carId, id)
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+	key := attachment.StorageKey
+	if thumbnail {
+		if attachment.ThumbnailKey == "" {
+			return models.Attachment{}, nil, errors.New("attachment has no thumbnail")
+		}
+		key = attachment.ThumbnailKey
+	}
+	content, err := a.blobs.Get(ctx, key)
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+	return attachment, content, nil
+}
+
+func (a AttachmentService) DeleteAttachment(ctx context.Context, carId string, id string) (models.Attachment, error) {
+	tracer := otel.Tracer("AttachmentService")
+	ctx, span := tracer.Start(ctx, "DeleteAttachment-Service")
+	defer span.End()
+
+	attachment, err := a.store.DeleteAttachment(ctx, carId, id)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	if err := a.DeleteBlobs(ctx, []models.Attachment{attachment}); err != nil {
+		logging.FromContext(ctx).Error("Error deleting attachment blobs", "error", err)
+	}
+	return attachment, nil
+}
+
+// DeleteBlobs removes the stored files of the given attachments, carrying on
+// past failures and returning the first one.
+func (a AttachmentService) DeleteBlobs(ctx context.Context, attachments []models.Attachment) error {
+	var firstErr error
+	for _, attachment := range attachments {
+		for _, key := range []string{attachment.StorageKey, attachment.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := a.blobs.Delete(ctx, key); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,79 @@
+package attachment
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"github.com/Akmyrat17/carm/models"
+)
+
+const (
+	thumbnailSize = 256
+	// maxImagePixels bounds the memory decoding an image takes; a small
+	// file can declare huge dimensions.
+	maxImagePixels = 50_000_000
+)
+
+// makeThumbnail scales an image down so its longest side is at most
+// thumbnailSize pixels, averaging the source pixels covered by each
+// thumbnail pixel, and encodes the result as JPEG. Images of more than
+// maxImagePixels pixels are rejected with models.ErrInvalidUpload before
+// they are decoded.
+func makeThumbnail(content []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return nil, fmt.Errorf("%w: image cannot have more than %d pixels", models.ErrInvalidUpload, maxImagePixels)
+	}
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, image.ErrFormat
+	}
+	dstWidth, dstHeight := width, height
+	if width > thumbnailSize || height > thumbnailSize {
+		if width >= height {
+			dstWidth, dstHeight = thumbnailSize, max(1, height*thumbnailSize/width)
+		} else {
+			dstWidth, dstHeight = max(1, width*thumbnailSize/height), thumbnailSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0 := bounds.Min.Y + y*height/dstHeight
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/dstHeight)
+		for x := 0; x < dstWidth; x++ {
+			x0 := bounds.Min.X + x*width/dstWidth
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/dstWidth)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa), n+1
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
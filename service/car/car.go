@@ -3,17 +3,25 @@ package car
 import (
 	"context"
 
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/metrics"
 	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/service"
 	"github.com/Akmyrat17/carm/store"
 	"go.opentelemetry.io/otel"
 )
 
+// DefaultBatchMaxSize is the batch limit used when CAR_BATCH_MAX_SIZE is
+// unset.
+const DefaultBatchMaxSize = 1000
+
 type CarService struct {
-	store store.CarStoreInterface
+	store       store.CarStoreInterface
+	attachments service.AttachmentServiceInterface
 }
 
-func NewCarService(store store.CarStoreInterface) *CarService {
-	return &CarService{store: store}
+func NewCarService(store store.CarStoreInterface, attachments service.AttachmentServiceInterface) *CarService {
+	return &CarService{store: store, attachments: attachments}
 }
 
 func (c CarService) GetCarById(ctx context.Context, id string) (models.Car, error) {
@@ -27,12 +35,24 @@ func (c CarService) GetCarById(ctx context.Context, id string) (models.Car, erro
 	return car, err
 }
 
-func (c CarService) GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]models.Car, error) {
+func (c CarService) GetCars(ctx context.Context, filter models.CarFilter) ([]models.Car, error) {
 	tracer := otel.Tracer("CarService")
-	ctx, span := tracer.Start(ctx, "GetCarByBrand-Service")
+	ctx, span := tracer.Start(ctx, "GetCars-Service")
 	defer span.End()
 
-	cars, err := c.store.GetCarByBrand(ctx, brand, isEngine)
+	cars, err := c.store.GetCars(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return cars, err
+}
+
+func (c CarService) GetCarsByEngineIds(ctx context.Context, engineIds []string, limit int, offset int) ([]models.Car, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "GetCarsByEngineIds-Service")
+	defer span.End()
+
+	cars, err := c.store.GetCarsByEngineIds(ctx, engineIds, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -44,10 +64,20 @@ func (c CarService) DeleteCar(ctx context.Context, id string) (models.Car, error
 	ctx, span := tracer.Start(ctx, "DeleteCar-Service")
 	defer span.End()
 
+	// Attachment records go with the car, so collect them first to be able
+	// to remove their files once the car is gone.
+	attachments, err := c.attachments.GetAttachments(ctx, id)
+	if err != nil {
+		return models.Car{}, err
+	}
 	car, err := c.store.DeleteCar(ctx, id)
 	if err != nil {
 		return models.Car{}, err
 	}
+	metrics.CarsDeleted.WithLabelValues(car.FuelType).Inc()
+	if err := c.attachments.DeleteBlobs(ctx, attachments); err != nil {
+		logging.FromContext(ctx).Error("Error deleting attachment files of car", "error", err)
+	}
 	return car, err
 }
 
@@ -78,5 +108,78 @@ func (c CarService) CreateCar(ctx context.Context, carReq *models.CarRequest) (m
 	if err != nil {
 		return models.Car{}, err
 	}
+	metrics.CarsCreated.WithLabelValues(car.FuelType).Inc()
 	return car, err
 }
+
+// RunCarBatch validates every operation before any of them touches the
+// database. Invalid operations fail on their own, or fail the whole batch
+// when it is atomic.
+func (c CarService) RunCarBatch(ctx context.Context, batchReq *models.CarBatchRequest) (models.CarBatchResponse, error) {
+	tracer := otel.Tracer("CarService")
+	ctx, span := tracer.Start(ctx, "RunCarBatch-Service")
+	defer span.End()
+
+	res := models.CarBatchResponse{Atomic: batchReq.Atomic == nil || *batchReq.Atomic}
+	res.Results = make([]models.CarBatchResult, len(batchReq.Operations))
+	var valid []models.CarBatchOperation
+	var positions []int
+	for i, operation := range batchReq.Operations {
+		res.Results[i] = models.CarBatchResult{Index: i, Op: operation.Op, Status: models.CarBatchSkipped}
+		if err := models.ValidateCarBatchOperation(operation); err != nil {
+			res.Results[i].Status = models.CarBatchFailed
+			res.Results[i].Error = err.Error()
+			continue
+		}
+		valid = append(valid, operation)
+		positions = append(positions, i)
+	}
+	if res.Atomic && len(valid) < len(batchReq.Operations) {
+		return res, nil
+	}
+
+	// As with DeleteCar, the attachment files of deleted cars are removed
+	// once the batch is committed.
+	attachments := make(map[int][]models.Attachment)
+	for i, operation := range valid {
+		if operation.Op != models.CarBatchDelete {
+			continue
+		}
+		carAttachments, err := c.attachments.GetAttachments(ctx, operation.ID)
+		if err != nil {
+			return models.CarBatchResponse{}, err
+		}
+		attachments[i] = carAttachments
+	}
+
+	results, committed, err := c.store.RunCarBatch(ctx, valid, res.Atomic)
+	if err != nil {
+		return models.CarBatchResponse{}, err
+	}
+	for i, result := range results {
+		result.Index = positions[i]
+		res.Results[positions[i]] = result
+	}
+	res.Committed = committed
+	if !committed {
+		return res, nil
+	}
+	for i, result := range results {
+		if result.Status != models.CarBatchSucceeded {
+			continue
+		}
+		switch result.Op {
+		case models.CarBatchCreate:
+			metrics.CarsCreated.WithLabelValues(result.Car.FuelType).Inc()
+		case models.CarBatchDelete:
+			metrics.CarsDeleted.WithLabelValues(result.Car.FuelType).Inc()
+		}
+		if len(attachments[i]) == 0 {
+			continue
+		}
+		if err := c.attachments.DeleteBlobs(ctx, attachments[i]); err != nil {
+			logging.FromContext(ctx).Error("Error deleting attachment files of car", "error", err)
+		}
+	}
+	return res, nil
+}
@@ -3,6 +3,7 @@ package engine
 import (
 	"context"
 
+	"github.com/Akmyrat17/carm/metrics"
 	"github.com/Akmyrat17/carm/models"
 	"github.com/Akmyrat17/carm/store"
 	"go.opentelemetry.io/otel"
@@ -27,6 +28,7 @@ func (e EngineService) CreateEngine(ctx context.Context, engineReq *models.Engin
 	if err != nil {
 		return models.Engine{}, err
 	}
+	metrics.EnginesCreated.Inc()
 	return engine, err
 }
 
@@ -41,6 +43,28 @@ func (e EngineService) GetEngineById(ctx context.Context, id string) (models.Eng
 	return engine, err
 }
 
+func (e EngineService) GetEngines(ctx context.Context) ([]models.Engine, error) {
+	tracer := otel.Tracer("EngineService")
+	ctx, span := tracer.Start(ctx, "GetEngines-Service")
+	defer span.End()
+	engines, err := e.store.GetEngines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return engines, err
+}
+
+func (e EngineService) GetEnginesByIds(ctx context.Context, ids []string) ([]models.Engine, error) {
+	tracer := otel.Tracer("EngineService")
+	ctx, span := tracer.Start(ctx, "GetEnginesByIds-Service")
+	defer span.End()
+	engines, err := e.store.GetEnginesByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return engines, err
+}
+
 func (e EngineService) UpdateEngine(ctx context.Context, id string, engineReq *models.EngineRequest) (models.Engine, error) {
 	tracer := otel.Tracer("EngineService")
 	ctx, span := tracer.Start(ctx, "UpdateEngine-Service")
@@ -63,5 +87,6 @@ func (e EngineService) DeleteEngine(ctx context.Context, id string) (models.Engi
 	if err != nil {
 		return models.Engine{}, err
 	}
+	metrics.EnginesDeleted.Inc()
 	return engine, err
 }
@@ -2,21 +2,89 @@ package service
 
 import (
 	"context"
+	"io"
 
 	"github.com/Akmyrat17/carm/models"
 )
 
 type CarServiceInterface interface {
 	GetCarById(ctx context.Context, id string) (models.Car, error)
-	GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]models.Car, error)
+	GetCars(ctx context.Context, filter models.CarFilter) ([]models.Car, error)
+	GetCarsByEngineIds(ctx context.Context, engineIds []string, limit int, offset int) ([]models.Car, error)
 	DeleteCar(ctx context.Context, id string) (models.Car, error)
 	UpdateCar(ctx context.Context, id string, carReq *models.CarRequest) (models.Car, error)
 	CreateCar(ctx context.Context, carReq *models.CarRequest) (models.Car, error)
+	RunCarBatch(ctx context.Context, batchReq *models.CarBatchRequest) (models.CarBatchResponse, error)
 }
 
 type EngineServiceInterface interface {
 	CreateEngine(ctx context.Context, engineReq *models.EngineRequest) (models.Engine, error)
 	GetEngineById(ctx context.Context, id string) (models.Engine, error)
+	GetEngines(ctx context.Context) ([]models.Engine, error)
+	GetEnginesByIds(ctx context.Context, ids []string) ([]models.Engine, error)
 	UpdateEngine(ctx context.Context, id string, engineReq *models.EngineRequest) (models.Engine, error)
 	DeleteEngine(ctx context.Context, id string) (models.Engine, error)
 }
+
+type ServiceRecordServiceInterface interface {
+	GetServiceRecords(ctx context.Context, carId string) ([]models.ServiceRecord, error)
+	GetServiceRecordById(ctx context.Context, carId string, id string) (models.ServiceRecord, error)
+	CreateServiceRecord(ctx context.Context, carId string, recordReq *models.ServiceRecordRequest) (models.ServiceRecord, error)
+	UpdateServiceRecord(ctx context.Context, carId string, id string, recordReq *models.ServiceRecordRequest) (models.ServiceRecord, error)
+	DeleteServiceRecord(ctx context.Context, carId string, id string) (models.ServiceRecord, error)
+	GetNextServiceDue(ctx context.Context, carId string) (models.NextServiceDue, error)
+}
+
+type OdometerServiceInterface interface {
+	GetOdometerReadings(ctx context.Context, carId string) ([]models.OdometerReading, error)
+	CreateOdometerReading(ctx context.Context, carId string, readingReq *models.OdometerReadingRequest) (models.OdometerReading, error)
+}
+
+type AttachmentServiceInterface interface {
+	GetAttachments(ctx context.Context, carId string) ([]models.Attachment, error)
+	UploadAttachment(ctx context.Context, carId string, upload *models.AttachmentUpload) (models.Attachment, error)
+	OpenAttachment(ctx context.Context, carId string, id string, thumbnail bool) (models.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, carId string, id string) (models.Attachment, error)
+	DeleteBlobs(ctx context.Context, attachments []models.Attachment) error
+}
+
+type LocationServiceInterface interface {
+	GetLocations(ctx context.Context) ([]models.Location, error)
+	GetLocationById(ctx context.Context, id string) (models.Location, error)
+	CreateLocation(ctx context.Context, locationReq *models.LocationRequest) (models.Location, error)
+	UpdateLocation(ctx context.Context, id string, locationReq *models.LocationRequest) (models.Location, error)
+	DeleteLocation(ctx context.Context, id string) (models.Location, error)
+	GetLocationCounts(ctx context.Context) ([]models.LocationCount, error)
+	TransferCar(ctx context.Context, carId string, transferReq *models.CarTransferRequest) (models.CarMovement, error)
+	GetCarMovements(ctx context.Context, carId string) ([]models.CarMovement, error)
+}
+
+type TenantServiceInterface interface {
+	GetTenants(ctx context.Context) ([]models.Tenant, error)
+	GetTenantById(ctx context.Context, id string) (models.Tenant, error)
+	CreateTenant(ctx context.Context, tenantReq *models.TenantRequest) (models.Tenant, error)
+	UpdateTenant(ctx context.Context, id string, tenantReq *models.TenantRequest) (models.Tenant, error)
+	DeleteTenant(ctx context.Context, id string) (models.Tenant, error)
+}
+
+type WebhookServiceInterface interface {
+	GetWebhooks(ctx context.Context) ([]models.Webhook, error)
+	GetWebhookById(ctx context.Context, id string) (models.Webhook, error)
+	CreateWebhook(ctx context.Context, webhookReq *models.WebhookRequest) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, id string, webhookReq *models.WebhookRequest) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) (models.Webhook, error)
+	GetDeliveries(ctx context.Context, webhookId string) ([]models.WebhookDelivery, error)
+}
+
+type UserServiceInterface interface {
+	GetUsers(ctx context.Context) ([]models.User, error)
+	CreateUser(ctx context.Context, userReq *models.UserRequest) (models.User, error)
+	Authenticate(ctx context.Context, username string, password string) (models.User, error)
+}
+
+type APIKeyServiceInterface interface {
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	GetAPIKeyById(ctx context.Context, id string) (models.APIKey, error)
+	CreateAPIKey(ctx context.Context, keyReq *models.APIKeyRequest) (models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) (models.APIKey, error)
+}
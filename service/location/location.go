@@ -0,0 +1,155 @@
+package location
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+	"go.opentelemetry.io/otel"
+)
+
+type LocationService struct {
+	store store.LocationStoreInterface
+}
+
+func NewLocationService(store store.LocationStoreInterface) *LocationService {
+	return &LocationService{store: store}
+}
+
+func (l LocationService) GetLocations(ctx context.Context) ([]models.Location, error) {
+	tracer := otel.Tracer("LocationService")
+	ctx, span := tracer.Start(ctx, "GetLocations-Service")
+	defer span.End()
+
+	locations, err := l.store.GetLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return locations, err
+}
+
+func (l LocationService) GetLocationById(ctx context.Context, id string) (models.Location, error) {
+	tracer := otel.Tracer("LocationService")
+	ctx, span := tracer.Start(ctx, "GetLocationById-Service")
+	defer span.End()
+
+	location, err := l.store.GetLocationById(ctx, id)
+	if err != nil {
+		return models.Location{}, err
+	}
+	return location, err
+}
+
+func (l LocationService) CreateLocation(ctx context.Context, locationReq *models.LocationRequest) (models.Location, error) {
+	tracer := otel.Tracer("LocationService")
+	ctx, span := tracer.Start(ctx, "CreateLocation-Service")
+	defer span.End()
+
+	if err := l.validateParent(ctx, "", locationReq); err != nil {
+		return models.Location{}, err
+	}
+	location, err := l.store.CreateLocation(ctx, locationReq)
+	if err != nil {
+		return models.Location{}, err
+	}
+	return location, err
+}
+
+func (l LocationService) UpdateLocation(ctx context.Context, id string, locationReq *models.LocationRequest) (models.Location, error) {
+	tracer := otel.Tracer("LocationService")
+	ctx, span := tracer.Start(ctx, "UpdateLocation-Service")
+	defer span.End()
+
+	if err := l.validateParent(ctx, id, locationReq); err != nil {
+		return models.Location{}, err
+	}
+	location, err := l.store.UpdateLocation(ctx, id, locationReq)
+	if err != nil {
+		return models.Location{}, err
+	}
+	return location, err
+}
+
+func (l LocationService) DeleteLocation(ctx context.Context, id string) (models.Location, error) {
+	tracer := otel.Tracer("LocationService")
+	ctx, span := tracer.Start(ctx, "DeleteLocation-Service")
+	defer span.End()
+
+	location, err := l.store.DeleteLocation(ctx, id)
+	if err != nil {
+		return models.Location{}, err
+	}
+	return location, err
+}
+
+func (l LocationService) GetLocationCounts(ctx context.Context) ([]models.LocationCount, error) {
+	tracer := otel.Tracer("LocationService")
+	ctx, span := tracer.Start(ctx, "GetLocationCounts-Service")
+	defer span.End()
+
+	counts, err := l.store.GetLocationCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return counts, err
+}
+
+func (l LocationService) TransferCar(ctx context.Context, carId string, transferReq *models.CarTransferRequest) (models.CarMovement, error) {
+	tracer := otel.Tracer("LocationService")
+	ctx, span := tracer.Start(ctx, "TransferCar-Service")
+	defer span.End()
+
+	if err := models.ValidateCarTransferRequest(*transferReq); err != nil {
+		return models.CarMovement{}, err
+	}
+	movement, err := l.store.TransferCar(ctx, carId, transferReq)
+	if err != nil {
+		return models.CarMovement{}, err
+	}
+	return movement, err
+}
+
+func (l LocationService) GetCarMovements(ctx context.Context, carId string) ([]models.CarMovement, error) {
+	tracer := otel.Tracer("LocationService")
+	ctx, span := tracer.Start(ctx, "GetCarMovements-Service")
+	defer span.End()
+
+	movements, err := l.store.GetCarMovements(ctx, carId)
+	if err != nil {
+		return nil, err
+	}
+	return movements, err
+}
+
+// validateParent checks the request and, for lots, that the parent is an
+// existing dealership other than the location itself. A location that
+// still has lots cannot become a lot, since lots cannot nest.
+func (l LocationService) validateParent(ctx context.Context, id string, locationReq *models.LocationRequest) error {
+	if err := models.ValidateLocationRequest(*locationReq); err != nil {
+		return err
+	}
+	if id != "" && locationReq.Type == models.LocationTypeLot {
+		hasLots, err := l.store.HasLots(ctx, id)
+		if err != nil {
+			return err
+		}
+		if hasLots {
+			return errors.New("a location with lots cannot become a lot")
+		}
+	}
+	if locationReq.ParentID == nil {
+		return nil
+	}
+	if locationReq.ParentID.String() == id {
+		return errors.New("a location cannot be its own parent")
+	}
+	parent, err := l.store.GetLocationById(ctx, locationReq.ParentID.String())
+	if err != nil {
+		return err
+	}
+	if parent.Type != models.LocationTypeDealership {
+		return errors.New("a lot must belong to a dealership")
+	}
+	return nil
+}
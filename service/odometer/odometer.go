@@ -0,0 +1,44 @@
+package odometer
+
+import (
+	"context"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+	"go.opentelemetry.io/otel"
+)
+
+type OdometerService struct {
+	store store.OdometerStoreInterface
+}
+
+func NewOdometerService(store store.OdometerStoreInterface) *OdometerService {
+	return &OdometerService{store: store}
+}
+
+func (o OdometerService) GetOdometerReadings(ctx context.Context, carId string) ([]models.OdometerReading, error) {
+	tracer := otel.Tracer("OdometerService")
+	ctx, span := tracer.Start(ctx, "GetOdometerReadings-Service")
+	defer span.End()
+
+	readings, err := o.store.GetOdometerReadings(ctx, carId)
+	if err != nil {
+		return nil, err
+	}
+	return readings, err
+}
+
+func (o OdometerService) CreateOdometerReading(ctx context.Context, carId string, readingReq *models.OdometerReadingRequest) (models.OdometerReading, error) {
+	tracer := otel.Tracer("OdometerService")
+	ctx, span := tracer.Start(ctx, "CreateOdometerReading-Service")
+	defer span.End()
+
+	if err := models.ValidateOdometerReadingRequest(*readingReq); err != nil {
+		return models.OdometerReading{}, err
+	}
+	reading, err := o.store.CreateOdometerReading(ctx, carId, readingReq)
+	if err != nil {
+		return models.OdometerReading{}, err
+	}
+	return reading, err
+}
@@ -0,0 +1,182 @@
+package servicerecord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+// DefaultIntervals are used for any fuel type that is not overridden by
+// SERVICE_INTERVALS. Cars have no engine type of their own, so the fuel
+// type stands in for it.
+var DefaultIntervals = map[string]models.ServiceInterval{
+	"Gasoline": {Kilometers: 10000, Months: 12},
+	"Diesel":   {Kilometers: 15000, Months: 12},
+	"Hybrid":   {Kilometers: 15000, Months: 12},
+	"Electric": {Kilometers: 30000, Months: 24},
+}
+
+type ServiceRecordService struct {
+	store     store.ServiceRecordStoreInterface
+	carStore  store.CarStoreInterface
+	intervals map[string]models.ServiceInterval
+}
+
+func NewServiceRecordService(store store.ServiceRecordStoreInterface, carStore store.CarStoreInterface, intervals map[string]models.ServiceInterval) *ServiceRecordService {
+	return &ServiceRecordService{store: store, carStore: carStore, intervals: intervals}
+}
+
+// ParseIntervals reads intervals in the form "Gasoline:10000:12,Diesel:15000:12"
+// (fuel type, kilometers, months) on top of DefaultIntervals. The fuel
+// type must be one of models.FuelTypes.
+func ParseIntervals(value string) (map[string]models.ServiceInterval, error) {
+	intervals := make(map[string]models.ServiceInterval, len(DefaultIntervals))
+	for fuelType, interval := range DefaultIntervals {
+		intervals[fuelType] = interval
+	}
+	if strings.TrimSpace(value) == "" {
+		return intervals, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid service interval %q, expected fuel_type:kilometers:months", entry)
+		}
+		if !models.ValidFuelType(parts[0]) {
+			return nil, fmt.Errorf("unknown fuel type %q in service interval %q, expected one of %s", parts[0], entry, strings.Join(models.FuelTypes, ", "))
+		}
+		kilometers, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || kilometers <= 0 {
+			return nil, fmt.Errorf("invalid kilometers in service interval %q", entry)
+		}
+		months, err := strconv.Atoi(parts[2])
+		if err != nil || months <= 0 {
+			return nil, fmt.Errorf("invalid months in service interval %q", entry)
+		}
+		intervals[parts[0]] = models.ServiceInterval{Kilometers: kilometers, Months: months}
+	}
+	return intervals, nil
+}
+
+func (s ServiceRecordService) GetServiceRecords(ctx context.Context, carId string) ([]models.ServiceRecord, error) {
+	tracer := otel.Tracer("ServiceRecordService")
+	ctx, span := tracer.Start(ctx, "GetServiceRecords-Service")
+	defer span.End()
+
+	records, err := s.store.GetServiceRecords(ctx, carId)
+	if err != nil {
+		return nil, err
+	}
+	return records, err
+}
+
+func (s ServiceRecordService) GetServiceRecordById(ctx context.Context, carId string, id string) (models.ServiceRecord, error) {
+	tracer := otel.Tracer("ServiceRecordService")
+	ctx, span := tracer.Start(ctx, "GetServiceRecordById-Service")
+	defer span.End()
+
+	record, err := s.store.GetServiceRecordById(ctx, carId, id)
+	if err != nil {
+		return models.ServiceRecord{}, err
+	}
+	return record, err
+}
+
+func (s ServiceRecordService) CreateServiceRecord(ctx context.Context, carId string, recordReq *models.ServiceRecordRequest) (models.ServiceRecord, error) {
+	tracer := otel.Tracer("ServiceRecordService")
+	ctx, span := tracer.Start(ctx, "CreateServiceRecord-Service")
+	defer span.End()
+
+	if err := models.ValidateServiceRecordRequest(*recordReq); err != nil {
+		return models.ServiceRecord{}, err
+	}
+	record, err := s.store.CreateServiceRecord(ctx, carId, recordReq)
+	if err != nil {
+		return models.ServiceRecord{}, err
+	}
+	return record, err
+}
+
+func (s ServiceRecordService) UpdateServiceRecord(ctx context.Context, carId string, id string, recordReq *models.ServiceRecordRequest) (models.ServiceRecord, error) {
+	tracer := otel.Tracer("ServiceRecordService")
+	ctx, span := tracer.Start(ctx, "UpdateServiceRecord-Service")
+	defer span.End()
+
+	if err := models.ValidateServiceRecordRequest(*recordReq); err != nil {
+		return models.ServiceRecord{}, err
+	}
+	record, err := s.store.UpdateServiceRecord(ctx, carId, id, recordReq)
+	if err != nil {
+		return models.ServiceRecord{}, err
+	}
+	return record, err
+}
+
+func (s ServiceRecordService) DeleteServiceRecord(ctx context.Context, carId string, id string) (models.ServiceRecord, error) {
+	tracer := otel.Tracer("ServiceRecordService")
+	ctx, span := tracer.Start(ctx, "DeleteServiceRecord-Service")
+	defer span.End()
+
+	record, err := s.store.DeleteServiceRecord(ctx, carId, id)
+	if err != nil {
+		return models.ServiceRecord{}, err
+	}
+	return record, err
+}
+
+// GetNextServiceDue works out when the car is next due for service from its
+// most recent record and the interval configured for its fuel type. A car
+// with no records is due one interval after it was added.
+func (s ServiceRecordService) GetNextServiceDue(ctx context.Context, carId string) (models.NextServiceDue, error) {
+	tracer := otel.Tracer("ServiceRecordService")
+	ctx, span := tracer.Start(ctx, "GetNextServiceDue-Service")
+	defer span.End()
+
+	car, err := s.carStore.GetCarById(ctx, carId)
+	if err != nil {
+		return models.NextServiceDue{}, err
+	}
+	if car.ID == uuid.Nil {
+		return models.NextServiceDue{}, errors.New("car not found in database")
+	}
+	interval, ok := s.intervals[car.FuelType]
+	if !ok {
+		return models.NextServiceDue{}, fmt.Errorf("no service interval configured for fuel type %s", car.FuelType)
+	}
+
+	records, err := s.store.GetServiceRecords(ctx, carId)
+	if err != nil {
+		return models.NextServiceDue{}, err
+	}
+
+	due := models.NextServiceDue{
+		CarID:    car.ID,
+		FuelType: car.FuelType,
+		Interval: interval,
+	}
+	from := car.CreatedAt
+	for _, record := range records {
+		if due.LastServiceDate == nil || record.Date.After(*due.LastServiceDate) {
+			date := record.Date
+			due.LastServiceDate = &date
+		}
+		if record.Odometer > due.LastOdometer {
+			due.LastOdometer = record.Odometer
+		}
+	}
+	if due.LastServiceDate != nil {
+		from = *due.LastServiceDate
+	}
+	due.DueDate = from.AddDate(0, interval.Months, 0)
+	due.DueOdometer = due.LastOdometer + interval.Kilometers
+	due.Overdue = time.Now().After(due.DueDate) || (car.Mileage > 0 && car.Mileage >= due.DueOdometer)
+	return due, nil
+}
@@ -0,0 +1,88 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+	"github.com/Akmyrat17/carm/tenant"
+	"go.opentelemetry.io/otel"
+)
+
+type TenantService struct {
+	store store.TenantStoreInterface
+}
+
+func NewTenantService(store store.TenantStoreInterface) *TenantService {
+	return &TenantService{store: store}
+}
+
+func (t TenantService) GetTenants(ctx context.Context) ([]models.Tenant, error) {
+	tracer := otel.Tracer("TenantService")
+	ctx, span := tracer.Start(ctx, "GetTenants-Service")
+	defer span.End()
+
+	tenants, err := t.store.GetTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tenants, err
+}
+
+func (t TenantService) GetTenantById(ctx context.Context, id string) (models.Tenant, error) {
+	tracer := otel.Tracer("TenantService")
+	ctx, span := tracer.Start(ctx, "GetTenantById-Service")
+	defer span.End()
+
+	result, err := t.store.GetTenantById(ctx, id)
+	if err != nil {
+		return models.Tenant{}, err
+	}
+	return result, err
+}
+
+func (t TenantService) CreateTenant(ctx context.Context, tenantReq *models.TenantRequest) (models.Tenant, error) {
+	tracer := otel.Tracer("TenantService")
+	ctx, span := tracer.Start(ctx, "CreateTenant-Service")
+	defer span.End()
+
+	if err := models.ValidateTenantRequest(*tenantReq); err != nil {
+		return models.Tenant{}, err
+	}
+	result, err := t.store.CreateTenant(ctx, tenantReq)
+	if err != nil {
+		return models.Tenant{}, err
+	}
+	return result, err
+}
+
+func (t TenantService) UpdateTenant(ctx context.Context, id string, tenantReq *models.TenantRequest) (models.Tenant, error) {
+	tracer := otel.Tracer("TenantService")
+	ctx, span := tracer.Start(ctx, "UpdateTenant-Service")
+	defer span.End()
+
+	if err := models.ValidateTenantRequest(*tenantReq); err != nil {
+		return models.Tenant{}, err
+	}
+	result, err := t.store.UpdateTenant(ctx, id, tenantReq)
+	if err != nil {
+		return models.Tenant{}, err
+	}
+	return result, err
+}
+
+func (t TenantService) DeleteTenant(ctx context.Context, id string) (models.Tenant, error) {
+	tracer := otel.Tracer("TenantService")
+	ctx, span := tracer.Start(ctx, "DeleteTenant-Service")
+	defer span.End()
+
+	if id == tenant.Default.String() {
+		return models.Tenant{}, errors.New("the default tenant cannot be deleted")
+	}
+	result, err := t.store.DeleteTenant(ctx, id)
+	if err != nil {
+		return models.Tenant{}, err
+	}
+	return result, err
+}
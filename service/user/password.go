@@ -0,0 +1,62 @@
+package user
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	hashScheme     = "pbkdf2-sha256"
+	hashIterations = 600000
+	saltLength     = 16
+	keyLength      = 32
+)
+
+var errMalformedHash = errors.New("malformed password hash")
+
+// HashPassword returns "pbkdf2-sha256$<iterations>$<salt>$<key>" with the
+// salt and key base64 encoded. The iteration count is stored so that it
+// can be raised without invalidating existing hashes.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, hashIterations, keyLength)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s$%d$%s$%s", hashScheme, hashIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func CheckPassword(hash string, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != hashScheme {
+		return false, errMalformedHash
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false, errMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false, errMalformedHash
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(want) == 0 {
+		return false, errMalformedHash
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(key, want) == 1, nil
+}
@@ -0,0 +1,123 @@
+package user
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+	"github.com/Akmyrat17/carm/tenant"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	bootstrapUsername = "admin"
+	bootstrapPassword = "admin"
+)
+
+type UserService struct {
+	store store.UserStoreInterface
+}
+
+func NewUserService(store store.UserStoreInterface) *UserService {
+	return &UserService{store: store}
+}
+
+func (u UserService) GetUsers(ctx context.Context) ([]models.User, error) {
+	tracer := otel.Tracer("UserService")
+	ctx, span := tracer.Start(ctx, "GetUsers-Service")
+	defer span.End()
+
+	users, err := u.store.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return users, err
+}
+
+func (u UserService) CreateUser(ctx context.Context, userReq *models.UserRequest) (models.User, error) {
+	tracer := otel.Tracer("UserService")
+	ctx, span := tracer.Start(ctx, "CreateUser-Service")
+	defer span.End()
+
+	if userReq.Role == "" {
+		userReq.Role = models.UserRoleMember
+	}
+	if err := models.ValidateUserRequest(*userReq); err != nil {
+		return models.User{}, err
+	}
+	if userReq.Role == models.UserRolePlatformAdmin {
+		tenantId, err := tenant.FromContext(ctx)
+		if err != nil {
+			return models.User{}, err
+		}
+		if tenantId != tenant.Default {
+			return models.User{}, errors.New("platform admins must belong to the default tenant")
+		}
+	}
+	existing, err := u.store.GetUserByUsername(ctx, userReq.Username)
+	if err != nil {
+		return models.User{}, err
+	}
+	if existing.Username != "" {
+		return models.User{}, errors.New("username is already taken")
+	}
+	passwordHash, err := HashPassword(userReq.Password)
+	if err != nil {
+		return models.User{}, err
+	}
+	user, err := u.store.CreateUser(ctx, userReq, passwordHash)
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, err
+}
+
+// Authenticate returns the user of the tenant in ctx with the given
+// credentials, or a zero User when there is no such user. A known user
+// with another password gets models.ErrWrongPassword.
+func (u UserService) Authenticate(ctx context.Context, username string, password string) (models.User, error) {
+	tracer := otel.Tracer("UserService")
+	ctx, span := tracer.Start(ctx, "Authenticate-Service")
+	defer span.End()
+
+	user, err := u.store.GetUserByUsername(ctx, username)
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.Username == "" {
+		return u.bootstrapAdmin(ctx, username, password)
+	}
+	ok, err := CheckPassword(user.PasswordHash, password)
+	if err != nil {
+		return models.User{}, err
+	}
+	if !ok {
+		return models.User{}, models.ErrWrongPassword
+	}
+	return user, nil
+}
+
+// bootstrapAdmin lets admin/admin sign in to the default tenant as a
+// platform admin until the first user is created, so that there is
+// someone to create it.
+func (u UserService) bootstrapAdmin(ctx context.Context, username string, password string) (models.User, error) {
+	if username != bootstrapUsername || password != bootstrapPassword {
+		return models.User{}, nil
+	}
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return models.User{}, err
+	}
+	if tenantId != tenant.Default {
+		return models.User{}, nil
+	}
+	hasUsers, err := u.store.HasUsers(ctx)
+	if err != nil {
+		return models.User{}, err
+	}
+	if hasUsers {
+		return models.User{}, nil
+	}
+	return models.User{TenantID: tenant.Default, Username: bootstrapUsername, Role: models.UserRolePlatformAdmin}, nil
+}
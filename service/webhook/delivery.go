@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+)
+
+const (
+	// MaxAttempts is how often a delivery is tried before it is marked
+	// failed.
+	MaxAttempts = 8
+
+	pollInterval   = 5 * time.Second
+	batchSize      = 20
+	requestTimeout = 10 * time.Second
+	// lease keeps a claimed delivery from being picked up again while it
+	// is being sent.
+	lease = 2 * requestTimeout
+
+	firstRetryDelay = 30 * time.Second
+	maxRetryDelay   = 6 * time.Hour
+)
+
+// Run sends due deliveries until ctx is cancelled. Failed attempts are
+// retried with exponential backoff; the queue lives in Postgres, so
+// pending deliveries survive restarts and can be shared by several
+// instances.
+func (s WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		s.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s WebhookService) deliverDue(ctx context.Context) {
+	for {
+		deliveries, err := s.store.ClaimDeliveries(ctx, batchSize, lease)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error claiming webhook deliveries", "error", err)
+			return
+		}
+		for _, delivery := range deliveries {
+			s.deliver(ctx, delivery)
+		}
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+func (s WebhookService) deliver(ctx context.Context, delivery models.WebhookDelivery) {
+	delivery.Attempts++
+	statusCode, err := s.send(ctx, delivery)
+	if statusCode != 0 {
+		delivery.ResponseStatus = &statusCode
+	}
+	now := time.Now()
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= MaxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(retryDelay(delivery.Attempts))
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = &next
+	}
+	if err := s.store.UpdateDelivery(ctx, delivery); err != nil {
+		logging.FromContext(ctx).Error("Error updating webhook delivery", "error", err)
+	}
+}
+
+// send posts the payload signed with the webhook secret. Receivers verify
+// X-Carm-Signature as the hex HMAC-SHA256 of "<X-Carm-Timestamp>.<body>".
+func (s WebhookService) send(ctx context.Context, delivery models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "carm-webhooks")
+	req.Header.Set("X-Carm-Event", delivery.EventType)
+	req.Header.Set("X-Carm-Delivery", delivery.ID.String())
+	req.Header.Set("X-Carm-Timestamp", timestamp)
+	req.Header.Set("X-Carm-Signature", "sha256="+Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// publicOnly refuses connections to addresses that are not public. It
+// runs after the name is resolved, on every connection, so a webhook
+// whose name later resolves to an internal address is refused as well.
+func publicOnly(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("webhook address %s: %w", address, err)
+	}
+	if !models.PublicAddress(addrPort.Addr()) {
+		return fmt.Errorf("webhook address %s is not public", addrPort.Addr())
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 signature of a delivery body.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay doubles the wait after every failed attempt.
+func retryDelay(attempts int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Akmyrat17/carm/events"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+type WebhookService struct {
+	store  store.WebhookStoreInterface
+	client *http.Client
+}
+
+func NewWebhookService(store store.WebhookStoreInterface) *WebhookService {
+	// Every connection, redirects included, goes through publicOnly; a
+	// proxy would connect on the webhook's behalf, so none is used.
+	dialer := &net.Dialer{Timeout: requestTimeout, Control: publicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &WebhookService{store: store, client: &http.Client{Timeout: requestTimeout, Transport: transport}}
+}
+
+func (s WebhookService) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	tracer := otel.Tracer("WebhookService")
+	ctx, span := tracer.Start(ctx, "GetWebhooks-Service")
+	defer span.End()
+
+	webhooks, err := s.store.GetWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, err
+}
+
+func (s WebhookService) GetWebhookById(ctx context.Context, id string) (models.Webhook, error) {
+	tracer := otel.Tracer("WebhookService")
+	ctx, span := tracer.Start(ctx, "GetWebhookById-Service")
+	defer span.End()
+
+	webhook, err := s.store.GetWebhookById(ctx, id)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	return webhook, err
+}
+
+// CreateWebhook generates the signing secret; the response is the only
+// place it is ever shown.
+func (s WebhookService) CreateWebhook(ctx context.Context, webhookReq *models.WebhookRequest) (models.Webhook, error) {
+	tracer := otel.Tracer("WebhookService")
+	ctx, span := tracer.Start(ctx, "CreateWebhook-Service")
+	defer span.End()
+
+	if err := models.ValidateWebhookRequest(*webhookReq, events.Types); err != nil {
+		return models.Webhook{}, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return models.Webhook{}, err
+	}
+	webhook, err := s.store.CreateWebhook(ctx, webhookReq, hex.EncodeToString(secret))
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	return webhook, err
+}
+
+func (s WebhookService) UpdateWebhook(ctx context.Context, id string, webhookReq *models.WebhookRequest) (models.Webhook, error) {
+	tracer := otel.Tracer("WebhookService")
+	ctx, span := tracer.Start(ctx, "UpdateWebhook-Service")
+	defer span.End()
+
+	if err := models.ValidateWebhookRequest(*webhookReq, events.Types); err != nil {
+		return models.Webhook{}, err
+	}
+	webhook, err := s.store.UpdateWebhook(ctx, id, webhookReq)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	return webhook, err
+}
+
+func (s WebhookService) DeleteWebhook(ctx context.Context, id string) (models.Webhook, error) {
+	tracer := otel.Tracer("WebhookService")
+	ctx, span := tracer.Start(ctx, "DeleteWebhook-Service")
+	defer span.End()
+
+	webhook, err := s.store.DeleteWebhook(ctx, id)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	return webhook, err
+}
+
+func (s WebhookService) GetDeliveries(ctx context.Context, webhookId string) ([]models.WebhookDelivery, error) {
+	tracer := otel.Tracer("WebhookService")
+	ctx, span := tracer.Start(ctx, "GetDeliveries-Service")
+	defer span.End()
+
+	if _, err := s.store.GetWebhookById(ctx, webhookId); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.store.GetDeliveries(ctx, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, err
+}
+
+// QueueDeliveries queues a delivery of the event for every webhook of the
+// tenant that subscribes to it. The deliveries are sent by Run. Queueing
+// the same event key twice is a no-op.
+func (s WebhookService) QueueDeliveries(ctx context.Context, event events.Event) error {
+	tracer := otel.Tracer("WebhookService")
+	ctx, span := tracer.Start(ctx, "QueueDeliveries-Service")
+	defer span.End()
+
+	webhooks, err := s.store.GetSubscribedWebhooks(ctx, event.Type())
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	deliveries := make([]models.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		payload, err := json.Marshal(map[string]interface{}{
+			"id":   event.Key,
+			"type": event.Type(),
+			"time": event.Time,
+			"data": event.Data,
+		})
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			ID:            uuid.New(),
+			WebhookID:     webhook.ID,
+			EventType:     event.Type(),
+			EventKey:      event.Key,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: &event.Time,
+			CreatedAt:     event.Time,
+		})
+	}
+	return s.store.CreateDeliveries(ctx, deliveries)
+}
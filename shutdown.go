@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/Akmyrat17/carm/grpcserver"
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/telemetry"
+	"google.golang.org/grpc"
+)
+
+// workers runs the background loops, such as the outbox relay, until they
+// are stopped.
+type workers struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newWorkers hands logger to the workers through their context.
+func newWorkers(logger *slog.Logger) *workers {
+	ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logger))
+	return &workers{ctx: ctx, cancel: cancel}
+}
+
+func (w *workers) Go(run func(ctx context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		run(w.ctx)
+	}()
+}
+
+// Stop cancels the workers and waits until they return or ctx expires.
+func (w *workers) Stop(ctx context.Context) error {
+	w.cancel()
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shutdown stops the server in dependency order: the gRPC health service
+// turns NOT_SERVING, the listeners close and in-flight requests finish,
+// then the background workers stop, the remaining spans and metrics are
+// flushed and the database pool is closed last. Steps still waiting when
+// ctx expires are cut short.
+func shutdown(ctx context.Context, httpServer *http.Server, grpcServer *grpcserver.Server, background *workers, providers *telemetry.Providers, db *sql.DB) {
+	grpcServer.ShutdownHealth()
+	var servers sync.WaitGroup
+	servers.Add(2)
+	go func() {
+		defer servers.Done()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			logging.FromContext(ctx).Error("Error draining HTTP requests", "error", err)
+			httpServer.Close()
+		}
+	}()
+	go func() {
+		defer servers.Done()
+		stopGRPC(ctx, grpcServer.Server)
+	}()
+	servers.Wait()
+
+	if err := background.Stop(ctx); err != nil {
+		logging.FromContext(ctx).Error("Error stopping background workers", "error", err)
+	}
+	if err := providers.Shutdown(ctx); err != nil {
+		logging.FromContext(ctx).Error("Error flushing telemetry", "error", err)
+	}
+	if err := db.Close(); err != nil {
+		logging.FromContext(ctx).Error("Error closing database", "error", err)
+	}
+}
+
+// stopGRPC lets running calls finish, cancelling them once ctx expires.
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logging.FromContext(ctx).Error("Error draining gRPC calls", "error", ctx.Err())
+		server.Stop()
+		<-done
+	}
+}
@@ -0,0 +1,143 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+type APIKeyStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *APIKeyStore {
+	return &APIKeyStore{db: db}
+}
+
+func (s APIKeyStore) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	tracer := otel.Tracer("APIKeyStore")
+	ctx, span := tracer.Start(ctx, "GetAPIKeys-Store")
+	defer span.End()
+	var keys []models.APIKey
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return keys, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, tenant_id, name, prefix, scopes, created_by, created_at, last_used_at, revoked_at FROM api_key WHERE tenant_id = $1 ORDER BY created_at", tenantId)
+	if err != nil {
+		return keys, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key models.APIKey
+		err := rows.Scan(&key.ID, &key.TenantID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedBy, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s APIKeyStore) GetAPIKeyById(ctx context.Context, id string) (models.APIKey, error) {
+	tracer := otel.Tracer("APIKeyStore")
+	ctx, span := tracer.Start(ctx, "GetAPIKeyById-Store")
+	defer span.End()
+	var key models.APIKey
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return key, err
+	}
+
+	err = s.db.QueryRowContext(ctx, "SELECT id, tenant_id, name, prefix, scopes, created_by, created_at, last_used_at, revoked_at FROM api_key WHERE id = $1 AND tenant_id = $2", id, tenantId).Scan(&key.ID, &key.TenantID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedBy, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return key, errors.New("api key not found in database")
+		}
+		return key, err
+	}
+	return key, nil
+}
+
+// GetAPIKeyByHash looks the key up in every tenant, as the tenant of a
+// request is only known once its key is. It returns a zero APIKey when
+// there is no such key.
+func (s APIKeyStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error) {
+	tracer := otel.Tracer("APIKeyStore")
+	ctx, span := tracer.Start(ctx, "GetAPIKeyByHash-Store")
+	defer span.End()
+	var key models.APIKey
+
+	err := s.db.QueryRowContext(ctx, "SELECT id, tenant_id, name, prefix, scopes, created_by, created_at, last_used_at, revoked_at FROM api_key WHERE key_hash = $1", keyHash).Scan(&key.ID, &key.TenantID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedBy, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return key, nil
+		}
+		return key, err
+	}
+	return key, nil
+}
+
+func (s APIKeyStore) CreateAPIKey(ctx context.Context, keyReq *models.APIKeyRequest, prefix string, keyHash string) (models.APIKey, error) {
+	tracer := otel.Tracer("APIKeyStore")
+	ctx, span := tracer.Start(ctx, "CreateAPIKey-Store")
+	defer span.End()
+	var createdKey models.APIKey
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return createdKey, err
+	}
+
+	query := `INSERT INTO api_key (id, tenant_id, name, prefix, key_hash, scopes, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, tenant_id, name, prefix, scopes, created_by, created_at`
+	err = s.db.QueryRowContext(ctx, query, uuid.New(), tenantId, keyReq.Name, prefix, keyHash, pq.Array(keyReq.Scopes), keyReq.CreatedBy, time.Now()).Scan(&createdKey.ID, &createdKey.TenantID, &createdKey.Name, &createdKey.Prefix, pq.Array(&createdKey.Scopes), &createdKey.CreatedBy, &createdKey.CreatedAt)
+	if err != nil {
+		return createdKey, err
+	}
+	return createdKey, nil
+}
+
+// RevokeAPIKey keeps the revocation time of keys revoked before.
+func (s APIKeyStore) RevokeAPIKey(ctx context.Context, id string) (models.APIKey, error) {
+	tracer := otel.Tracer("APIKeyStore")
+	ctx, span := tracer.Start(ctx, "RevokeAPIKey-Store")
+	defer span.End()
+	var revokedKey models.APIKey
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return revokedKey, err
+	}
+
+	query :=
+		`UPDATE api_key
+		SET revoked_at = COALESCE(revoked_at, $1)
+			WHERE id = $2 AND tenant_id = $3
+				RETURNING id, tenant_id, name, prefix, scopes, created_by, created_at, last_used_at, revoked_at`
+	err = s.db.QueryRowContext(ctx, query, time.Now(), id, tenantId).Scan(&revokedKey.ID, &revokedKey.TenantID, &revokedKey.Name, &revokedKey.Prefix, pq.Array(&revokedKey.Scopes), &revokedKey.CreatedBy, &revokedKey.CreatedAt, &revokedKey.LastUsedAt, &revokedKey.RevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return revokedKey, errors.New("api key not found in database")
+		}
+		return revokedKey, err
+	}
+	return revokedKey, nil
+}
+
+func (s APIKeyStore) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	tracer := otel.Tracer("APIKeyStore")
+	ctx, span := tracer.Start(ctx, "TouchAPIKey-Store")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE api_key SET last_used_at = $1 WHERE id = $2", usedAt, id)
+	return err
+}
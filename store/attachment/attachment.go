@@ -0,0 +1,139 @@
+package attachment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+type AttachmentStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *AttachmentStore {
+	return &AttachmentStore{db: db}
+}
+
+func (a AttachmentStore) GetAttachments(ctx context.Context, carId string) ([]models.Attachment, error) {
+	tracer := otel.Tracer("AttachmentStore")
+	ctx, span := tracer.Start(ctx, "GetAttachments-Store")
+	defer span.End()
+	var attachments []models.Attachment
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return attachments, err
+	}
+
+	query := `SELECT id, car_id, kind, file_name, content_type, size, storage_key, thumbnail_key, created_at FROM attachment WHERE car_id = $1 AND tenant_id = $2 ORDER BY created_at`
+	rows, err := a.db.QueryContext(ctx, query, carId, tenantId)
+	if err != nil {
+		return attachments, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var attachment models.Attachment
+		err := rows.Scan(&attachment.ID, &attachment.CarID, &attachment.Kind, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.StorageKey, &attachment.ThumbnailKey, &attachment.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		attachment.HasThumbnail = attachment.ThumbnailKey != ""
+		attachments = append(attachments, attachment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (a AttachmentStore) GetAttachmentById(ctx context.Context, carId string, id string) (models.Attachment, error) {
+	tracer := otel.Tracer("AttachmentStore")
+	ctx, span := tracer.Start(ctx, "GetAttachmentById-Store")
+	defer span.End()
+	var attachment models.Attachment
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return attachment, err
+	}
+
+	query := `SELECT id, car_id, kind, file_name, content_type, size, storage_key, thumbnail_key, created_at FROM attachment WHERE car_id = $1 AND id = $2 AND tenant_id = $3`
+	err = a.db.QueryRowContext(ctx, query, carId, id, tenantId).Scan(&attachment.ID, &attachment.CarID, &attachment.Kind, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.StorageKey, &attachment.ThumbnailKey, &attachment.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return attachment, errors.New("attachment not found in database")
+		}
+		return attachment, err
+	}
+	attachment.HasThumbnail = attachment.ThumbnailKey != ""
+	return attachment, nil
+}
+
+func (a AttachmentStore) CreateAttachment(ctx context.Context, attachment *models.Attachment) (models.Attachment, error) {
+	tracer := otel.Tracer("AttachmentStore")
+	ctx, span := tracer.Start(ctx, "CreateAttachment-Store")
+	defer span.End()
+	var createdAttachment models.Attachment
+
+	var carId uuid.UUID
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return createdAttachment, err
+	}
+	err = a.db.QueryRowContext(ctx, "SELECT id FROM car WHERE id = $1 AND tenant_id = $2", attachment.CarID, tenantId).Scan(&carId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return createdAttachment, errors.New("car not found in database")
+		}
+		return createdAttachment, err
+	}
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return createdAttachment, err
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
+			}
+		} else {
+			if commitErr := tx.Commit(); commitErr != nil {
+				logging.FromContext(ctx).Error("Error committing transaction", "error", commitErr)
+			}
+		}
+	}()
+	query := `INSERT INTO attachment (id, tenant_id, car_id, kind, file_name, content_type, size, storage_key, thumbnail_key, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, car_id, kind, file_name, content_type, size, storage_key, thumbnail_key, created_at`
+	err = tx.QueryRowContext(ctx, query, attachment.ID, tenantId, attachment.CarID, attachment.Kind, attachment.FileName, attachment.ContentType, attachment.Size, attachment.StorageKey, attachment.ThumbnailKey, attachment.CreatedAt).Scan(&createdAttachment.ID, &createdAttachment.CarID, &createdAttachment.Kind, &createdAttachment.FileName, &createdAttachment.ContentType, &createdAttachment.Size, &createdAttachment.StorageKey, &createdAttachment.ThumbnailKey, &createdAttachment.CreatedAt)
+	if err != nil {
+		return createdAttachment, err
+	}
+	createdAttachment.HasThumbnail = createdAttachment.ThumbnailKey != ""
+	return createdAttachment, nil
+}
+
+func (a AttachmentStore) DeleteAttachment(ctx context.Context, carId string, id string) (models.Attachment, error) {
+	tracer := otel.Tracer("AttachmentStore")
+	ctx, span := tracer.Start(ctx, "DeleteAttachment-Store")
+	defer span.End()
+	var deletedAttachment models.Attachment
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return deletedAttachment, err
+	}
+
+	query := `DELETE FROM attachment WHERE car_id = $1 AND id = $2 AND tenant_id = $3 RETURNING id, car_id, kind, file_name, content_type, size, storage_key, thumbnail_key, created_at`
+	err = a.db.QueryRowContext(ctx, query, carId, id, tenantId).Scan(&deletedAttachment.ID, &deletedAttachment.CarID, &deletedAttachment.Kind, &deletedAttachment.FileName, &deletedAttachment.ContentType, &deletedAttachment.Size, &deletedAttachment.StorageKey, &deletedAttachment.ThumbnailKey, &deletedAttachment.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return deletedAttachment, errors.New("attachment not found in database")
+		}
+		return deletedAttachment, err
+	}
+	deletedAttachment.HasThumbnail = deletedAttachment.ThumbnailKey != ""
+	return deletedAttachment, nil
+}
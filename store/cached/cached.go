@@ -0,0 +1,113 @@
+// Package cached puts a cache in front of the car and engine lookups. The
+// stores here decorate the Postgres stores: GetCarById and GetEngineById
+// read through the cache, and every write that changes a car or an engine
+// deletes its entry afterwards. Entries also expire after a TTL, which
+// bounds how long a lookup racing with a write can keep a stale value.
+//
+// Cache errors are logged and the lookup goes to the database, so a cache
+// outage only costs speed.
+package cached
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Akmyrat17/carm/cache"
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	hitCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "carm_cache_hits_total",
+			Help: "Total number of lookups answered from the cache, by entity",
+		},
+		[]string{"entity"},
+	)
+
+	missCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "carm_cache_misses_total",
+			Help: "Total number of lookups that went to the database, by entity",
+		},
+		[]string{"entity"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(hitCounter, missCounter)
+}
+
+// entityCache holds the entries of one entity, such as cars.
+type entityCache struct {
+	cache  cache.Cache
+	entity string
+	ttl    time.Duration
+}
+
+// key returns the cache key of the entity with id in the tenant of ctx.
+// Ids are normalized, so that every spelling of a UUID shares one entry;
+// ids that are not UUIDs are not cached.
+func (c entityCache) key(ctx context.Context, id string) (string, bool) {
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return "", false
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return "", false
+	}
+	return c.entity + ":" + tenantId.String() + ":" + parsed.String(), true
+}
+
+// get decodes the entry of key into value and reports whether it was
+// found.
+func (c entityCache) get(ctx context.Context, key string, value any) bool {
+	data, ok, err := c.cache.Get(ctx, key)
+	if err == nil && ok {
+		err = json.Unmarshal(data, value)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error reading cache", "entity", c.entity, "error", err)
+		ok = false
+	}
+	if ok {
+		hitCounter.WithLabelValues(c.entity).Inc()
+	} else {
+		missCounter.WithLabelValues(c.entity).Inc()
+	}
+	return ok
+}
+
+func (c entityCache) set(ctx context.Context, key string, value any) {
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = c.cache.Set(ctx, key, data, c.ttl)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error writing cache", "entity", c.entity, "error", err)
+	}
+}
+
+// invalidate deletes the entries of ids.
+func (c entityCache) invalidate(ctx context.Context, ids ...string) {
+	var keys []string
+	for _, id := range ids {
+		if key, ok := c.key(ctx, id); ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	// The write is done even if the client went away; its entries must
+	// still go.
+	ctx = context.WithoutCancel(ctx)
+	if err := c.cache.Delete(ctx, keys...); err != nil {
+		logging.FromContext(ctx).Error("Error invalidating cache", "entity", c.entity, "error", err)
+	}
+}
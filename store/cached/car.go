@@ -0,0 +1,122 @@
+package cached
+
+import (
+	"context"
+	"time"
+
+	"github.com/Akmyrat17/carm/cache"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+// CarStore caches GetCarById. The engine of a cached car is looked up
+// again through the engine store, which may be cached as well, so that
+// engine updates show up on the car right away.
+type CarStore struct {
+	store.CarStoreInterface
+	engines store.EngineStoreInterface
+	cars    entityCache
+}
+
+func NewCarStore(next store.CarStoreInterface, engines store.EngineStoreInterface, c cache.Cache, ttl time.Duration) *CarStore {
+	return &CarStore{
+		CarStoreInterface: next,
+		engines:           engines,
+		cars:              entityCache{cache: c, entity: "car", ttl: ttl},
+	}
+}
+
+func (s *CarStore) GetCarById(ctx context.Context, id string) (models.Car, error) {
+	tracer := otel.Tracer("CarCache")
+	ctx, span := tracer.Start(ctx, "GetCarById-Cache")
+	defer span.End()
+
+	key, ok := s.cars.key(ctx, id)
+	if !ok {
+		return s.CarStoreInterface.GetCarById(ctx, id)
+	}
+	var car models.Car
+	cached := s.cars.get(ctx, key, &car)
+	if cached {
+		// Engines that cars use cannot be deleted, so a missing engine
+		// means a stale entry or a race with another change; the
+		// database has the last word.
+		engine, err := s.engines.GetEngineById(ctx, car.Engine.ID.String())
+		if err == nil {
+			car.Engine = engine
+			return car, nil
+		}
+	}
+	car, err := s.CarStoreInterface.GetCarById(ctx, id)
+	if err != nil {
+		return car, err
+	}
+	if car.ID == uuid.Nil {
+		if cached {
+			s.cars.invalidate(ctx, id)
+		}
+		return car, nil
+	}
+	s.cars.set(ctx, key, car)
+	return car, nil
+}
+
+func (s *CarStore) UpdateCar(ctx context.Context, id string, carReq *models.CarRequest) (models.Car, error) {
+	defer s.cars.invalidate(ctx, id)
+	return s.CarStoreInterface.UpdateCar(ctx, id, carReq)
+}
+
+func (s *CarStore) DeleteCar(ctx context.Context, id string) (models.Car, error) {
+	defer s.cars.invalidate(ctx, id)
+	return s.CarStoreInterface.DeleteCar(ctx, id)
+}
+
+func (s *CarStore) RunCarBatch(ctx context.Context, operations []models.CarBatchOperation, atomic bool) ([]models.CarBatchResult, bool, error) {
+	var ids []string
+	for _, operation := range operations {
+		if operation.ID != "" {
+			ids = append(ids, operation.ID)
+		}
+	}
+	defer s.cars.invalidate(ctx, ids...)
+	return s.CarStoreInterface.RunCarBatch(ctx, operations, atomic)
+}
+
+// OdometerStore drops the cached car of a new reading, whose mileage it
+// changes.
+type OdometerStore struct {
+	store.OdometerStoreInterface
+	cars entityCache
+}
+
+func NewOdometerStore(next store.OdometerStoreInterface, c cache.Cache, ttl time.Duration) *OdometerStore {
+	return &OdometerStore{
+		OdometerStoreInterface: next,
+		cars:                   entityCache{cache: c, entity: "car", ttl: ttl},
+	}
+}
+
+func (s *OdometerStore) CreateOdometerReading(ctx context.Context, carId string, readingReq *models.OdometerReadingRequest) (models.OdometerReading, error) {
+	defer s.cars.invalidate(ctx, carId)
+	return s.OdometerStoreInterface.CreateOdometerReading(ctx, carId, readingReq)
+}
+
+// LocationStore drops the cached car of a transfer, which moves it.
+type LocationStore struct {
+	store.LocationStoreInterface
+	cars entityCache
+}
+
+func NewLocationStore(next store.LocationStoreInterface, c cache.Cache, ttl time.Duration) *LocationStore {
+	return &LocationStore{
+		LocationStoreInterface: next,
+		cars:                   entityCache{cache: c, entity: "car", ttl: ttl},
+	}
+}
+
+func (s *LocationStore) TransferCar(ctx context.Context, carId string, transferReq *models.CarTransferRequest) (models.CarMovement, error) {
+	defer s.cars.invalidate(ctx, carId)
+	return s.LocationStoreInterface.TransferCar(ctx, carId, transferReq)
+}
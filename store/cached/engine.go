@@ -0,0 +1,55 @@
+package cached
+
+import (
+	"context"
+	"time"
+
+	"github.com/Akmyrat17/carm/cache"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/store"
+	"go.opentelemetry.io/otel"
+)
+
+// EngineStore caches GetEngineById.
+type EngineStore struct {
+	store.EngineStoreInterface
+	engines entityCache
+}
+
+func NewEngineStore(next store.EngineStoreInterface, c cache.Cache, ttl time.Duration) *EngineStore {
+	return &EngineStore{
+		EngineStoreInterface: next,
+		engines:              entityCache{cache: c, entity: "engine", ttl: ttl},
+	}
+}
+
+func (s *EngineStore) GetEngineById(ctx context.Context, id string) (models.Engine, error) {
+	tracer := otel.Tracer("EngineCache")
+	ctx, span := tracer.Start(ctx, "GetEngineById-Cache")
+	defer span.End()
+
+	key, ok := s.engines.key(ctx, id)
+	if !ok {
+		return s.EngineStoreInterface.GetEngineById(ctx, id)
+	}
+	var engine models.Engine
+	if s.engines.get(ctx, key, &engine) {
+		return engine, nil
+	}
+	engine, err := s.EngineStoreInterface.GetEngineById(ctx, id)
+	if err != nil {
+		return engine, err
+	}
+	s.engines.set(ctx, key, engine)
+	return engine, nil
+}
+
+func (s *EngineStore) UpdateEngine(ctx context.Context, id string, engineReq *models.EngineRequest) (models.Engine, error) {
+	defer s.engines.invalidate(ctx, id)
+	return s.EngineStoreInterface.UpdateEngine(ctx, id, engineReq)
+}
+
+func (s *EngineStore) DeleteEngine(ctx context.Context, id string) (models.Engine, error) {
+	defer s.engines.invalidate(ctx, id)
+	return s.EngineStoreInterface.DeleteEngine(ctx, id)
+}
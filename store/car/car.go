@@ -4,10 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Akmyrat17/carm/events"
 	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/outbox"
+	"github.com/Akmyrat17/carm/tenant"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.opentelemetry.io/otel"
 )
 
@@ -24,10 +30,14 @@ func (c CarStore) GetCarById(ctx context.Context, id string) (models.Car, error)
 	ctx, span := tracer.Start(ctx, "GetCarById-Store")
 	defer span.End()
 	var car models.Car
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return car, err
+	}
 
-	query := `SELECT c.id,c.name,c.year,c.brand,c.fuel_type,c.price,c.created_at,c.updated_at,e.id,e.displacement,e.no_of_cylinders,e.car_range FROM car c LEFT JOIN engine e ON c.engine_id = e.id WHERE c.id = $1`
-	row := c.db.QueryRowContext(ctx, query, id)
-	err := row.Scan(&car.ID, &car.Name, &car.Year, &car.Brand, &car.FuelType, &car.Price, &car.CreatedAt, &car.UpdatedAt, &car.Engine.ID, &car.Engine.Displacement, &car.Engine.NoOfCylinders, &car.Engine.CarRange)
+	query := `SELECT c.id,c.name,c.year,c.brand,c.fuel_type,c.price,c.mileage,c.location_id,c.created_at,c.updated_at,e.id,e.displacement,e.no_of_cylinders,e.car_range FROM car c LEFT JOIN engine e ON c.engine_id = e.id WHERE c.id = $1 AND c.tenant_id = $2`
+	row := c.db.QueryRowContext(ctx, query, id, tenantId)
+	err = row.Scan(&car.ID, &car.Name, &car.Year, &car.Brand, &car.FuelType, &car.Price, &car.Mileage, &car.LocationID, &car.CreatedAt, &car.UpdatedAt, &car.Engine.ID, &car.Engine.Displacement, &car.Engine.NoOfCylinders, &car.Engine.CarRange)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return car, nil
@@ -38,34 +48,48 @@ func (c CarStore) GetCarById(ctx context.Context, id string) (models.Car, error)
 	return car, nil
 }
 
-func (c CarStore) GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]models.Car, error) {
+func (c CarStore) GetCars(ctx context.Context, filter models.CarFilter) ([]models.Car, error) {
 	tracer := otel.Tracer("CarStore")
-	ctx, span := tracer.Start(ctx, "GetCarByBrand-Store")
+	ctx, span := tracer.Start(ctx, "GetCars-Store")
 	defer span.End()
 	var cars []models.Car
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return cars, err
+	}
 	var query string
-	if isEngine {
-		query = `SELECT c.id,c.name,c.year,c.brand,c.fuel_type,c.price,c.created_at,c.updated_at,e.id,e.displacement,e.no_of_cylinders,e.car_range FROM car c LEFT JOIN engine e ON c.engine_id = e.id WHERE c.brand = $1`
+	if filter.IsEngine {
+		query = `SELECT c.id,c.name,c.year,c.brand,c.fuel_type,c.price,c.mileage,c.location_id,c.created_at,c.updated_at,e.id,e.displacement,e.no_of_cylinders,e.car_range FROM car c LEFT JOIN engine e ON c.engine_id = e.id`
 	} else {
-		query = `SELECT c.id,c.name,c.year,c.brand,c.fuel_type,c.price,c.created_at,c.updated_at FROM car c WHERE c.brand = $1`
+		query = `SELECT c.id,c.name,c.year,c.brand,c.fuel_type,c.price,c.mileage,c.location_id,c.created_at,c.updated_at,c.engine_id FROM car c`
+	}
+	where, args := carFilterClause(tenantId, filter)
+	query += where + ` ORDER BY c.created_at, c.id`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
 	}
 
-	rows, err := c.db.QueryContext(ctx, query, brand)
+	rows, err := c.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return cars, err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var car models.Car
-		if isEngine {
+		if filter.IsEngine {
 			var engine models.Engine
-			err := rows.Scan(&car.ID, &car.Name, &car.Year, &car.Brand, &car.FuelType, &car.Price, &car.CreatedAt, &car.UpdatedAt, &engine.ID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange)
+			err := rows.Scan(&car.ID, &car.Name, &car.Year, &car.Brand, &car.FuelType, &car.Price, &car.Mileage, &car.LocationID, &car.CreatedAt, &car.UpdatedAt, &engine.ID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange)
 			if err != nil {
 				return nil, err
 			}
 			car.Engine = engine
 		} else {
-			err := rows.Scan(&car.ID, &car.Name, &car.Year, &car.Brand, &car.FuelType, &car.Price, &car.CreatedAt, &car.UpdatedAt)
+			err := rows.Scan(&car.ID, &car.Name, &car.Year, &car.Brand, &car.FuelType, &car.Price, &car.Mileage, &car.LocationID, &car.CreatedAt, &car.UpdatedAt, &car.Engine.ID)
 			if err != nil {
 				return nil, err
 			}
@@ -78,35 +102,108 @@ func (c CarStore) GetCarByBrand(ctx context.Context, brand string, isEngine bool
 	return cars, nil
 }
 
-func (c CarStore) CreateCar(ctx context.Context, carReq *models.CarRequest) (models.Car, error) {
+// GetCarsByEngineIds loads, in a single query, the cars of every engine in
+// engineIds, skipping the first offset cars of each engine and returning at
+// most limit of the rest, in the order GetCars lists them.
+func (c CarStore) GetCarsByEngineIds(ctx context.Context, engineIds []string, limit int, offset int) ([]models.Car, error) {
 	tracer := otel.Tracer("CarStore")
-	ctx, span := tracer.Start(ctx, "CreateCar-Store")
+	ctx, span := tracer.Start(ctx, "GetCarsByEngineIds-Store")
 	defer span.End()
-	var createdCar models.Car
-	var engineId uuid.UUID
+	var cars []models.Car
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return cars, err
+	}
 
-	err := c.db.QueryRowContext(ctx, "SELECT id FROM engine where id = $1", carReq.Engine.ID).Scan(&engineId)
+	query := `SELECT id,name,year,brand,fuel_type,price,mileage,location_id,created_at,updated_at,engine_id FROM (
+			SELECT c.*, ROW_NUMBER() OVER (PARTITION BY c.engine_id ORDER BY c.created_at, c.id) AS position
+			FROM car c WHERE c.engine_id = ANY($1::uuid[]) AND c.tenant_id = $2
+		) ranked
+		WHERE position > $3 AND position <= $3 + $4
+		ORDER BY engine_id, created_at, id`
+	rows, err := c.db.QueryContext(ctx, query, pq.Array(engineIds), tenantId, offset, limit)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return createdCar, errors.New("engine not found in database")
+		return cars, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var car models.Car
+		err := rows.Scan(&car.ID, &car.Name, &car.Year, &car.Brand, &car.FuelType, &car.Price, &car.Mileage, &car.LocationID, &car.CreatedAt, &car.UpdatedAt, &car.Engine.ID)
+		if err != nil {
+			return nil, err
 		}
-		return createdCar, err
+		cars = append(cars, car)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return cars, nil
+}
 
-	carId := uuid.New()
-	createdAt := time.Now()
-	updatedAt := createdAt
+// carFilterClause builds the WHERE clause and its positional arguments for
+// the tenant and the non-zero fields of filter.
+func carFilterClause(tenantId uuid.UUID, filter models.CarFilter) (string, []interface{}) {
+	conditions := []string{"c.tenant_id = $1"}
+	args := []interface{}{tenantId}
+	if filter.Brand != "" {
+		args = append(args, filter.Brand)
+		conditions = append(conditions, fmt.Sprintf("c.brand = $%d", len(args)))
+	}
+	if filter.MinMileage != nil {
+		args = append(args, *filter.MinMileage)
+		conditions = append(conditions, fmt.Sprintf("c.mileage >= $%d", len(args)))
+	}
+	if filter.MaxMileage != nil {
+		args = append(args, *filter.MaxMileage)
+		conditions = append(conditions, fmt.Sprintf("c.mileage <= $%d", len(args)))
+	}
+	if filter.EngineID != nil {
+		args = append(args, *filter.EngineID)
+		conditions = append(conditions, fmt.Sprintf("c.engine_id = $%d", len(args)))
+	}
+	if filter.LocationID != nil {
+		args = append(args, *filter.LocationID)
+		conditions = append(conditions, fmt.Sprintf(`c.location_id IN (
+			WITH RECURSIVE tree AS (
+				SELECT id FROM location WHERE id = $%d AND tenant_id = $1
+				UNION ALL
+				SELECT l.id FROM location l JOIN tree t ON l.parent_id = t.id
+			) SELECT id FROM tree)`, len(args)))
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
 
-	newCar := models.Car{
-		ID:        carId,
-		Name:      carReq.Name,
-		Year:      carReq.Year,
-		FuelType:  carReq.FuelType,
-		Price:     carReq.Price,
-		Engine:    carReq.Engine,
-		Brand:     carReq.Brand,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
+// CountCars counts the cars of every tenant by brand and fuel type, for
+// the inventory metrics.
+func (c CarStore) CountCars(ctx context.Context) ([]models.CarCount, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "CountCars-Store")
+	defer span.End()
+
+	rows, err := c.db.QueryContext(ctx, "SELECT brand, fuel_type, COUNT(*) FROM car GROUP BY brand, fuel_type")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var counts []models.CarCount
+	for rows.Next() {
+		var count models.CarCount
+		if err := rows.Scan(&count.Brand, &count.FuelType, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+func (c CarStore) CreateCar(ctx context.Context, carReq *models.CarRequest) (models.Car, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "CreateCar-Store")
+	defer span.End()
+	var createdCar models.Car
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return createdCar, err
 	}
 
 	// Begin the transaction
@@ -121,13 +218,8 @@ func (c CarStore) CreateCar(ctx context.Context, carReq *models.CarRequest) (mod
 			tx.Commit()
 		}
 	}()
-	query := `INSERT INTO car (id, name, year, brand, fuel_type, price, engine_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, name, year, brand, fuel_type, price, created_at, updated_at`
-	err = tx.QueryRowContext(ctx, query, newCar.ID, newCar.Name, newCar.Year, newCar.Brand, newCar.FuelType, newCar.Price, newCar.Engine.ID, newCar.CreatedAt, newCar.UpdatedAt).Scan(&createdCar.ID, &createdCar.Name, &createdCar.Year, &createdCar.Brand, &createdCar.FuelType, &createdCar.Price, &createdCar.CreatedAt, &createdCar.UpdatedAt)
-	if err != nil {
-		return createdCar, err
-	}
-
-	return createdCar, nil
+	createdCar, err = createCar(ctx, tx, tenantId, carReq)
+	return createdCar, err
 }
 
 func (c CarStore) UpdateCar(ctx context.Context, id string, carReq *models.CarRequest) (models.Car, error) {
@@ -135,6 +227,10 @@ func (c CarStore) UpdateCar(ctx context.Context, id string, carReq *models.CarRe
 	ctx, span := tracer.Start(ctx, "UpdateCar-Store")
 	defer span.End()
 	var updatedCar models.Car
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return updatedCar, err
+	}
 
 	tx, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -147,16 +243,8 @@ func (c CarStore) UpdateCar(ctx context.Context, id string, carReq *models.CarRe
 			tx.Commit()
 		}
 	}()
-	query :=
-		`UPDATE car 
-		SET name = $1, year = $2, brand = $3, fuel_type = $4, price = $5, engine_id = $6, updated_at = $7 
-			WHERE id = $8 
-				RETURNING id, name, year, brand, fuel_type, price, created_at, updated_at`
-	err = tx.QueryRowContext(ctx, query, carReq.Name, carReq.Year, carReq.Brand, carReq.FuelType, carReq.Price, carReq.Engine.ID, time.Now(), id).Scan(&updatedCar.ID, &updatedCar.Name, &updatedCar.Year, &updatedCar.Brand, &updatedCar.FuelType, &updatedCar.Price, &updatedCar.CreatedAt, &updatedCar.UpdatedAt)
-	if err != nil {
-		return updatedCar, err
-	}
-	return updatedCar, nil
+	updatedCar, err = updateCar(ctx, tx, tenantId, id, carReq)
+	return updatedCar, err
 }
 
 func (c CarStore) DeleteCar(ctx context.Context, id string) (models.Car, error) {
@@ -164,6 +252,10 @@ func (c CarStore) DeleteCar(ctx context.Context, id string) (models.Car, error)
 	ctx, span := tracer.Start(ctx, "DeleteCar-Store")
 	defer span.End()
 	var deletedCar models.Car
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return deletedCar, err
+	}
 
 	tx, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -176,15 +268,195 @@ func (c CarStore) DeleteCar(ctx context.Context, id string) (models.Car, error)
 			tx.Commit()
 		}
 	}()
+	deletedCar, err = deleteCar(ctx, tx, tenantId, id)
+	return deletedCar, err
+}
+
+// RunCarBatch applies the operations in one transaction. An atomic batch
+// stops and rolls back at the first failing operation; otherwise each
+// operation runs in its own savepoint, so a failure only undoes that
+// operation. The returned error is reserved for failures of the
+// transaction itself.
+func (c CarStore) RunCarBatch(ctx context.Context, operations []models.CarBatchOperation, atomic bool) ([]models.CarBatchResult, bool, error) {
+	tracer := otel.Tracer("CarStore")
+	ctx, span := tracer.Start(ctx, "RunCarBatch-Store")
+	defer span.End()
+	results := make([]models.CarBatchResult, len(operations))
+	for i, operation := range operations {
+		results[i] = models.CarBatchResult{Index: i, Op: operation.Op, Status: models.CarBatchSkipped}
+	}
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return results, false, err
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return results, false, err
+	}
+	defer tx.Rollback()
+
+	for i, operation := range operations {
+		if !atomic {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT car_batch"); err != nil {
+				return results, false, err
+			}
+		}
+		car, err := runCarOperation(ctx, tx, tenantId, operation)
+		if err != nil {
+			results[i].Status = models.CarBatchFailed
+			results[i].Error = err.Error()
+			if atomic {
+				rollBack(results)
+				return results, false, nil
+			}
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT car_batch"); err != nil {
+				return results, false, err
+			}
+			continue
+		}
+		if !atomic {
+			if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT car_batch"); err != nil {
+				return results, false, err
+			}
+		}
+		results[i].Status = models.CarBatchSucceeded
+		results[i].Car = &car
+	}
+	if err := tx.Commit(); err != nil {
+		return results, false, err
+	}
+	return results, true, nil
+}
 
-	err = tx.QueryRowContext(ctx, "SELECT id, name, year, brand, fuel_type,engine_id, price, created_at, updated_at FROM car WHERE id = $1", id).Scan(&deletedCar.ID, &deletedCar.Name, &deletedCar.Year, &deletedCar.Brand, &deletedCar.FuelType, &deletedCar.Engine.ID, &deletedCar.Price, &deletedCar.CreatedAt, &deletedCar.UpdatedAt)
+// rollBack marks the operations that succeeded before an atomic batch was
+// rolled back; none of their changes were kept.
+func rollBack(results []models.CarBatchResult) {
+	for i := range results {
+		if results[i].Status == models.CarBatchSucceeded {
+			results[i].Status = models.CarBatchRolledBack
+			results[i].Car = nil
+		}
+	}
+}
+
+func runCarOperation(ctx context.Context, tx *sql.Tx, tenantId uuid.UUID, operation models.CarBatchOperation) (models.Car, error) {
+	switch operation.Op {
+	case models.CarBatchCreate:
+		return createCar(ctx, tx, tenantId, operation.Car)
+	case models.CarBatchUpdate:
+		return updateCar(ctx, tx, tenantId, operation.ID, operation.Car)
+	case models.CarBatchDelete:
+		return deleteCar(ctx, tx, tenantId, operation.ID)
+	}
+	return models.Car{}, fmt.Errorf("unknown batch operation %q", operation.Op)
+}
+
+func createCar(ctx context.Context, tx *sql.Tx, tenantId uuid.UUID, carReq *models.CarRequest) (models.Car, error) {
+	var createdCar models.Car
+	var engineId uuid.UUID
+	err := tx.QueryRowContext(ctx, "SELECT id FROM engine where id = $1 AND tenant_id = $2", carReq.Engine.ID, tenantId).Scan(&engineId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return createdCar, errors.New("engine not found in database")
+		}
+		return createdCar, err
+	}
+	if carReq.LocationID != nil {
+		var locationId uuid.UUID
+		err = tx.QueryRowContext(ctx, "SELECT id FROM location WHERE id = $1 AND tenant_id = $2", *carReq.LocationID, tenantId).Scan(&locationId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return createdCar, errors.New("location not found in database")
+			}
+			return createdCar, err
+		}
+	}
+
+	carId := uuid.New()
+	createdAt := time.Now()
+	updatedAt := createdAt
+
+	newCar := models.Car{
+		ID:         carId,
+		Name:       carReq.Name,
+		Year:       carReq.Year,
+		FuelType:   carReq.FuelType,
+		Price:      carReq.Price,
+		Engine:     carReq.Engine,
+		Brand:      carReq.Brand,
+		LocationID: carReq.LocationID,
+		CreatedAt:  createdAt,
+		UpdatedAt:  updatedAt,
+	}
+
+	query := `INSERT INTO car (id, tenant_id, name, year, brand, fuel_type, price, engine_id, location_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, name, year, brand, fuel_type, price, mileage, location_id, created_at, updated_at`
+	err = tx.QueryRowContext(ctx, query, newCar.ID, tenantId, newCar.Name, newCar.Year, newCar.Brand, newCar.FuelType, newCar.Price, newCar.Engine.ID, newCar.LocationID, newCar.CreatedAt, newCar.UpdatedAt).Scan(&createdCar.ID, &createdCar.Name, &createdCar.Year, &createdCar.Brand, &createdCar.FuelType, &createdCar.Price, &createdCar.Mileage, &createdCar.LocationID, &createdCar.CreatedAt, &createdCar.UpdatedAt)
+	if err != nil {
+		return createdCar, err
+	}
+	err = outbox.Add(ctx, tx, carEvent(events.ActionCreated, createdCar))
+	if err != nil {
+		return createdCar, err
+	}
+
+	return createdCar, nil
+}
+
+func updateCar(ctx context.Context, tx *sql.Tx, tenantId uuid.UUID, id string, carReq *models.CarRequest) (models.Car, error) {
+	var updatedCar models.Car
+	var engineId uuid.UUID
+	err := tx.QueryRowContext(ctx, "SELECT id FROM engine where id = $1 AND tenant_id = $2", carReq.Engine.ID, tenantId).Scan(&engineId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return updatedCar, errors.New("engine not found in database")
+		}
+		return updatedCar, err
+	}
+
+	var previousPrice float64
+	err = tx.QueryRowContext(ctx, "SELECT price FROM car WHERE id = $1 AND tenant_id = $2 FOR UPDATE", id, tenantId).Scan(&previousPrice)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return updatedCar, errors.New("car not found in database")
+		}
+		return updatedCar, err
+	}
+	query :=
+		`UPDATE car 
+		SET name = $1, year = $2, brand = $3, fuel_type = $4, price = $5, engine_id = $6, updated_at = $7 
+			WHERE id = $8 AND tenant_id = $9
+				RETURNING id, name, year, brand, fuel_type, price, mileage, location_id, created_at, updated_at`
+	err = tx.QueryRowContext(ctx, query, carReq.Name, carReq.Year, carReq.Brand, carReq.FuelType, carReq.Price, carReq.Engine.ID, time.Now(), id, tenantId).Scan(&updatedCar.ID, &updatedCar.Name, &updatedCar.Year, &updatedCar.Brand, &updatedCar.FuelType, &updatedCar.Price, &updatedCar.Mileage, &updatedCar.LocationID, &updatedCar.CreatedAt, &updatedCar.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return updatedCar, errors.New("car not found in database")
+		}
+		return updatedCar, err
+	}
+	err = outbox.Add(ctx, tx, carEvent(events.ActionUpdated, updatedCar))
+	if err != nil {
+		return updatedCar, err
+	}
+	if previousPrice != updatedCar.Price {
+		err = outbox.Add(ctx, tx, carEvent(events.ActionRepriced, updatedCar))
+		if err != nil {
+			return updatedCar, err
+		}
+	}
+	return updatedCar, nil
+}
+
+func deleteCar(ctx context.Context, tx *sql.Tx, tenantId uuid.UUID, id string) (models.Car, error) {
+	var deletedCar models.Car
+	err := tx.QueryRowContext(ctx, "SELECT id, name, year, brand, fuel_type,engine_id, price, mileage, location_id, created_at, updated_at FROM car WHERE id = $1 AND tenant_id = $2", id, tenantId).Scan(&deletedCar.ID, &deletedCar.Name, &deletedCar.Year, &deletedCar.Brand, &deletedCar.FuelType, &deletedCar.Engine.ID, &deletedCar.Price, &deletedCar.Mileage, &deletedCar.LocationID, &deletedCar.CreatedAt, &deletedCar.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return deletedCar, errors.New("car not found in database")
 		}
 		return deletedCar, err
 	}
-	result, err := tx.ExecContext(ctx, "DELETE FROM car WHERE id = $1", id)
+	result, err := tx.ExecContext(ctx, "DELETE FROM car WHERE id = $1 AND tenant_id = $2", id, tenantId)
 	if err != nil {
 		return deletedCar, err
 	}
@@ -195,5 +467,13 @@ func (c CarStore) DeleteCar(ctx context.Context, id string) (models.Car, error)
 	if rowsAffected == 0 {
 		return deletedCar, errors.New("car not found in database")
 	}
+	err = outbox.Add(ctx, tx, carEvent(events.ActionDeleted, deletedCar))
+	if err != nil {
+		return deletedCar, err
+	}
 	return deletedCar, nil
 }
+
+func carEvent(action string, car models.Car) events.Event {
+	return events.Event{Entity: events.EntityCar, Action: action, Brand: car.Brand, Data: car}
+}
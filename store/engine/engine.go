@@ -7,8 +7,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Akmyrat17/carm/events"
+	"github.com/Akmyrat17/carm/logging"
 	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/outbox"
+	"github.com/Akmyrat17/carm/tenant"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.opentelemetry.io/otel"
 )
 
@@ -25,22 +30,11 @@ func (e EngineStore) GetEngineById(ctx context.Context, id string) (models.Engin
 	ctx, span := tracer.Start(ctx, "GetEngineById-Store")
 	defer span.End()
 	var engine models.Engine
-	tx, err := e.db.BeginTx(ctx, nil)
+	tenantId, err := tenant.FromContext(ctx)
 	if err != nil {
 		return engine, err
 	}
-	defer func() {
-		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				fmt.Printf("error rolling back transaction: %v", rbErr)
-			}
-		} else {
-			if commitErr := tx.Commit(); commitErr != nil {
-				fmt.Printf("error committing transaction: %v", commitErr)
-			}
-		}
-	}()
-	err = tx.QueryRowContext(ctx, "SELECT id, displacement, no_of_cylinders, car_range FROM engine WHERE id = $1", id).Scan(&engine.ID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange)
+	err = e.db.QueryRowContext(ctx, "SELECT id, displacement, no_of_cylinders, car_range FROM engine WHERE id = $1 AND tenant_id = $2", id, tenantId).Scan(&engine.ID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return engine, errors.New("engine not found in database")
@@ -50,10 +44,70 @@ func (e EngineStore) GetEngineById(ctx context.Context, id string) (models.Engin
 	return engine, nil
 }
 
+func (e EngineStore) GetEngines(ctx context.Context) ([]models.Engine, error) {
+	tracer := otel.Tracer("EngineStore")
+	ctx, span := tracer.Start(ctx, "GetEngines-Store")
+	defer span.End()
+	var engines []models.Engine
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return engines, err
+	}
+	rows, err := e.db.QueryContext(ctx, "SELECT id, displacement, no_of_cylinders, car_range, created_at, updated_at FROM engine WHERE tenant_id = $1 ORDER BY created_at, id", tenantId)
+	if err != nil {
+		return engines, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var engine models.Engine
+		if err := rows.Scan(&engine.ID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange, &engine.CreatedAt, &engine.UpdatedAt); err != nil {
+			return nil, err
+		}
+		engines = append(engines, engine)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return engines, nil
+}
+
+// GetEnginesByIds loads all engines with the given ids in a single query.
+// Ids that do not match an engine of the tenant are left out.
+func (e EngineStore) GetEnginesByIds(ctx context.Context, ids []string) ([]models.Engine, error) {
+	tracer := otel.Tracer("EngineStore")
+	ctx, span := tracer.Start(ctx, "GetEnginesByIds-Store")
+	defer span.End()
+	var engines []models.Engine
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return engines, err
+	}
+	rows, err := e.db.QueryContext(ctx, "SELECT id, displacement, no_of_cylinders, car_range, created_at, updated_at FROM engine WHERE id = ANY($1::uuid[]) AND tenant_id = $2", pq.Array(ids), tenantId)
+	if err != nil {
+		return engines, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var engine models.Engine
+		if err := rows.Scan(&engine.ID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange, &engine.CreatedAt, &engine.UpdatedAt); err != nil {
+			return nil, err
+		}
+		engines = append(engines, engine)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return engines, nil
+}
+
 func (e EngineStore) CreatedEngine(ctx context.Context, engineReq *models.EngineRequest) (models.Engine, error) {
 	tracer := otel.Tracer("EngineStore")
 	ctx, span := tracer.Start(ctx, "CerateEngine-Store")
 	defer span.End()
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return models.Engine{}, err
+	}
 	tx, err := e.db.BeginTx(ctx, nil)
 	if err != nil {
 		return models.Engine{}, err
@@ -61,11 +115,11 @@ func (e EngineStore) CreatedEngine(ctx context.Context, engineReq *models.Engine
 	defer func() {
 		if err != nil {
 			if rbErr := tx.Rollback(); rbErr != nil {
-				fmt.Printf("error rolling back transaction: %v", rbErr)
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
 			}
 		} else {
 			if commitErr := tx.Commit(); commitErr != nil {
-				fmt.Printf("error committing transaction: %v", commitErr)
+				logging.FromContext(ctx).Error("Error committing transaction", "error", commitErr)
 			}
 		}
 	}()
@@ -76,7 +130,7 @@ func (e EngineStore) CreatedEngine(ctx context.Context, engineReq *models.Engine
 	updatedAt := createdAt
 
 	_, err = tx.ExecContext(ctx,
-		"INSERT INTO engine (id, displacement, no_of_cylinders, car_range, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)", engineId, engineReq.Displacement, engineReq.NoOfCylinders, engineReq.CarRange, createdAt, updatedAt)
+		"INSERT INTO engine (id, tenant_id, displacement, no_of_cylinders, car_range, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)", engineId, tenantId, engineReq.Displacement, engineReq.NoOfCylinders, engineReq.CarRange, createdAt, updatedAt)
 
 	if err != nil {
 		return models.Engine{}, err
@@ -89,6 +143,10 @@ func (e EngineStore) CreatedEngine(ctx context.Context, engineReq *models.Engine
 		CreatedAt:     createdAt,
 		UpdatedAt:     updatedAt,
 	}
+	err = outbox.Add(ctx, tx, engineEvent(events.ActionCreated, engine))
+	if err != nil {
+		return models.Engine{}, err
+	}
 	return engine, nil
 
 }
@@ -97,6 +155,10 @@ func (e EngineStore) UpdateEngine(ctx context.Context, id string, engineReq *mod
 	tracer := otel.Tracer("EngineStore")
 	ctx, span := tracer.Start(ctx, "UpdateEngine-Store")
 	defer span.End()
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return models.Engine{}, err
+	}
 	engineId, err := uuid.Parse(id)
 	if err != nil {
 		return models.Engine{}, fmt.Errorf("invalid engine id: %v", err)
@@ -108,16 +170,16 @@ func (e EngineStore) UpdateEngine(ctx context.Context, id string, engineReq *mod
 	defer func() {
 		if err != nil {
 			if rbErr := tx.Rollback(); rbErr != nil {
-				fmt.Printf("error rolling back transaction: %v", rbErr)
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
 			}
 		} else {
 			if commitErr := tx.Commit(); commitErr != nil {
-				fmt.Printf("error committing transaction: %v", commitErr)
+				logging.FromContext(ctx).Error("Error committing transaction", "error", commitErr)
 			}
 		}
 	}()
 	result, err := tx.ExecContext(ctx,
-		"UPDATE engine SET displacement = $2, no_of_cylinders = $3, car_range = $4, updated_at = $5 WHERE id = $1", engineId, engineReq.Displacement, engineReq.NoOfCylinders, engineReq.CarRange, time.Now())
+		"UPDATE engine SET displacement = $2, no_of_cylinders = $3, car_range = $4, updated_at = $5 WHERE id = $1 AND tenant_id = $6", engineId, engineReq.Displacement, engineReq.NoOfCylinders, engineReq.CarRange, time.Now(), tenantId)
 	if err != nil {
 		return models.Engine{}, err
 	}
@@ -136,6 +198,10 @@ func (e EngineStore) UpdateEngine(ctx context.Context, id string, engineReq *mod
 		CarRange:      engineReq.CarRange,
 		UpdatedAt:     time.Now(),
 	}
+	err = outbox.Add(ctx, tx, engineEvent(events.ActionUpdated, engine))
+	if err != nil {
+		return models.Engine{}, err
+	}
 	return engine, nil
 }
 
@@ -144,6 +210,10 @@ func (e EngineStore) DeleteEngine(ctx context.Context, id string) (models.Engine
 	ctx, span := tracer.Start(ctx, "DeleteEngine-Store")
 	defer span.End()
 	var engine models.Engine
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return engine, err
+	}
 	tx, err := e.db.BeginTx(ctx, nil)
 	if err != nil {
 		return models.Engine{}, err
@@ -151,23 +221,37 @@ func (e EngineStore) DeleteEngine(ctx context.Context, id string) (models.Engine
 	defer func() {
 		if err != nil {
 			if rbErr := tx.Rollback(); rbErr != nil {
-				fmt.Printf("error rolling back transaction: %v", rbErr)
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
 			}
 		} else {
 			if commitErr := tx.Commit(); commitErr != nil {
-				fmt.Printf("error committing transaction: %v", commitErr)
+				logging.FromContext(ctx).Error("Error committing transaction", "error", commitErr)
 			}
 		}
 	}()
-	err = tx.QueryRowContext(ctx, "SELECT id, displacement, no_of_cylinders, car_range FROM engine WHERE id = $1", id).Scan(&engine.ID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange)
+	err = tx.QueryRowContext(ctx, "SELECT id, displacement, no_of_cylinders, car_range FROM engine WHERE id = $1 AND tenant_id = $2", id, tenantId).Scan(&engine.ID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return engine, errors.New("engine not found in database")
 		}
 		return engine, err
 	}
-	result, err := tx.ExecContext(ctx, "DELETE FROM engine WHERE id = $1", id)
+	var inUse bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM car WHERE engine_id = $1)", id).Scan(&inUse)
+	if err != nil {
+		return engine, err
+	}
+	if inUse {
+		err = models.ErrEngineInUse
+		return engine, err
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM engine WHERE id = $1 AND tenant_id = $2", id, tenantId)
 	if err != nil {
+		// A car added since the check still holds on to the engine.
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			err = models.ErrEngineInUse
+		}
 		return engine, err
 	}
 	rowsAffected, err := result.RowsAffected()
@@ -177,5 +261,13 @@ func (e EngineStore) DeleteEngine(ctx context.Context, id string) (models.Engine
 	if rowsAffected == 0 {
 		return engine, errors.New("engine not found in database")
 	}
+	err = outbox.Add(ctx, tx, engineEvent(events.ActionDeleted, engine))
+	if err != nil {
+		return engine, err
+	}
 	return engine, nil
 }
+
+func engineEvent(action string, engine models.Engine) events.Event {
+	return events.Event{Entity: events.EntityEngine, Action: action, Data: engine}
+}
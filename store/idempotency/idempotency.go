@@ -0,0 +1,118 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/tenant"
+	"go.opentelemetry.io/otel"
+)
+
+type IdempotencyStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *IdempotencyStore {
+	return &IdempotencyStore{db: db}
+}
+
+// ClaimIdempotencyKey stores record as in progress unless a live record
+// with the same key exists. Expired records and records still in progress
+// since before staleBefore, whose request died, are replaced. It reports
+// whether the caller now owns the key.
+func (s IdempotencyStore) ClaimIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord, staleBefore time.Time) (bool, error) {
+	tracer := otel.Tracer("IdempotencyStore")
+	ctx, span := tracer.Start(ctx, "ClaimIdempotencyKey-Store")
+	defer span.End()
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	query := `INSERT INTO idempotency_key (tenant_id, owner, key, method, path, request_hash, status_code, content_type, body, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 0, '', '', $7, $8)
+		ON CONFLICT (tenant_id, owner, key, method, path) DO UPDATE
+			SET request_hash = EXCLUDED.request_hash, status_code = 0, content_type = '', body = '', created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+			WHERE idempotency_key.expires_at < EXCLUDED.created_at
+				OR (idempotency_key.status_code = 0 AND idempotency_key.created_at < $9)
+		RETURNING key`
+	var key string
+	err = s.db.QueryRowContext(ctx, query, tenantId, record.Owner, record.Key, record.Method, record.Path, record.RequestHash, record.CreatedAt, record.ExpiresAt, staleBefore).Scan(&key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s IdempotencyStore) GetIdempotencyKey(ctx context.Context, owner string, key string, method string, path string) (models.IdempotencyRecord, error) {
+	tracer := otel.Tracer("IdempotencyStore")
+	ctx, span := tracer.Start(ctx, "GetIdempotencyKey-Store")
+	defer span.End()
+	var record models.IdempotencyRecord
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return record, err
+	}
+
+	query := `SELECT owner, key, method, path, request_hash, status_code, content_type, body, created_at, expires_at
+		FROM idempotency_key WHERE tenant_id = $1 AND owner = $2 AND key = $3 AND method = $4 AND path = $5`
+	err = s.db.QueryRowContext(ctx, query, tenantId, owner, key, method, path).Scan(&record.Owner, &record.Key, &record.Method, &record.Path, &record.RequestHash, &record.StatusCode, &record.ContentType, &record.Body, &record.CreatedAt, &record.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return record, errors.New("idempotency key not found in database")
+		}
+		return record, err
+	}
+	return record, nil
+}
+
+// CompleteIdempotencyKey stores the response of the request that claimed
+// the key.
+func (s IdempotencyStore) CompleteIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) error {
+	tracer := otel.Tracer("IdempotencyStore")
+	ctx, span := tracer.Start(ctx, "CompleteIdempotencyKey-Store")
+	defer span.End()
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE idempotency_key SET status_code = $1, content_type = $2, body = $3
+		WHERE tenant_id = $4 AND owner = $5 AND key = $6 AND method = $7 AND path = $8`
+	_, err = s.db.ExecContext(ctx, query, record.StatusCode, record.ContentType, record.Body, tenantId, record.Owner, record.Key, record.Method, record.Path)
+	return err
+}
+
+// DeleteIdempotencyKey releases a key whose request failed, so that the
+// client can retry it.
+func (s IdempotencyStore) DeleteIdempotencyKey(ctx context.Context, owner string, key string, method string, path string) error {
+	tracer := otel.Tracer("IdempotencyStore")
+	ctx, span := tracer.Start(ctx, "DeleteIdempotencyKey-Store")
+	defer span.End()
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM idempotency_key WHERE tenant_id = $1 AND owner = $2 AND key = $3 AND method = $4 AND path = $5", tenantId, owner, key, method, path)
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys removes expired keys of all tenants.
+func (s IdempotencyStore) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	tracer := otel.Tracer("IdempotencyStore")
+	ctx, span := tracer.Start(ctx, "DeleteExpiredIdempotencyKeys-Store")
+	defer span.End()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_key WHERE expires_at < $1", time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
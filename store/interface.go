@@ -2,21 +2,104 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/Akmyrat17/carm/models"
+	"github.com/google/uuid"
 )
 
 type CarStoreInterface interface {
 	GetCarById(ctx context.Context, id string) (models.Car, error)
-	GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]models.Car, error)
+	GetCars(ctx context.Context, filter models.CarFilter) ([]models.Car, error)
+	GetCarsByEngineIds(ctx context.Context, engineIds []string, limit int, offset int) ([]models.Car, error)
 	CreateCar(ctx context.Context, carReq *models.CarRequest) (models.Car, error)
 	UpdateCar(ctx context.Context, id string, carReq *models.CarRequest) (models.Car, error)
 	DeleteCar(ctx context.Context, id string) (models.Car, error)
+	RunCarBatch(ctx context.Context, operations []models.CarBatchOperation, atomic bool) ([]models.CarBatchResult, bool, error)
 }
 
 type EngineStoreInterface interface {
 	GetEngineById(ctx context.Context, id string) (models.Engine, error)
+	GetEngines(ctx context.Context) ([]models.Engine, error)
+	GetEnginesByIds(ctx context.Context, ids []string) ([]models.Engine, error)
 	CreatedEngine(ctx context.Context, engineReq *models.EngineRequest) (models.Engine, error)
 	UpdateEngine(ctx context.Context, id string, engineReq *models.EngineRequest) (models.Engine, error)
 	DeleteEngine(ctx context.Context, id string) (models.Engine, error)
 }
+
+type ServiceRecordStoreInterface interface {
+	GetServiceRecords(ctx context.Context, carId string) ([]models.ServiceRecord, error)
+	GetServiceRecordById(ctx context.Context, carId string, id string) (models.ServiceRecord, error)
+	CreateServiceRecord(ctx context.Context, carId string, recordReq *models.ServiceRecordRequest) (models.ServiceRecord, error)
+	UpdateServiceRecord(ctx context.Context, carId string, id string, recordReq *models.ServiceRecordRequest) (models.ServiceRecord, error)
+	DeleteServiceRecord(ctx context.Context, carId string, id string) (models.ServiceRecord, error)
+}
+
+type OdometerStoreInterface interface {
+	GetOdometerReadings(ctx context.Context, carId string) ([]models.OdometerReading, error)
+	CreateOdometerReading(ctx context.Context, carId string, readingReq *models.OdometerReadingRequest) (models.OdometerReading, error)
+}
+
+type AttachmentStoreInterface interface {
+	GetAttachments(ctx context.Context, carId string) ([]models.Attachment, error)
+	GetAttachmentById(ctx context.Context, carId string, id string) (models.Attachment, error)
+	CreateAttachment(ctx context.Context, attachment *models.Attachment) (models.Attachment, error)
+	DeleteAttachment(ctx context.Context, carId string, id string) (models.Attachment, error)
+}
+
+type LocationStoreInterface interface {
+	GetLocations(ctx context.Context) ([]models.Location, error)
+	GetLocationById(ctx context.Context, id string) (models.Location, error)
+	CreateLocation(ctx context.Context, locationReq *models.LocationRequest) (models.Location, error)
+	UpdateLocation(ctx context.Context, id string, locationReq *models.LocationRequest) (models.Location, error)
+	DeleteLocation(ctx context.Context, id string) (models.Location, error)
+	HasLots(ctx context.Context, id string) (bool, error)
+	GetLocationCounts(ctx context.Context) ([]models.LocationCount, error)
+	TransferCar(ctx context.Context, carId string, transferReq *models.CarTransferRequest) (models.CarMovement, error)
+	GetCarMovements(ctx context.Context, carId string) ([]models.CarMovement, error)
+}
+
+type TenantStoreInterface interface {
+	GetTenants(ctx context.Context) ([]models.Tenant, error)
+	GetTenantById(ctx context.Context, id string) (models.Tenant, error)
+	CreateTenant(ctx context.Context, tenantReq *models.TenantRequest) (models.Tenant, error)
+	UpdateTenant(ctx context.Context, id string, tenantReq *models.TenantRequest) (models.Tenant, error)
+	DeleteTenant(ctx context.Context, id string) (models.Tenant, error)
+}
+
+type WebhookStoreInterface interface {
+	GetWebhooks(ctx context.Context) ([]models.Webhook, error)
+	GetWebhookById(ctx context.Context, id string) (models.Webhook, error)
+	CreateWebhook(ctx context.Context, webhookReq *models.WebhookRequest, secret string) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, id string, webhookReq *models.WebhookRequest) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) (models.Webhook, error)
+	GetSubscribedWebhooks(ctx context.Context, eventType string) ([]models.Webhook, error)
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, webhookId string) ([]models.WebhookDelivery, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+}
+
+type IdempotencyStoreInterface interface {
+	ClaimIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord, staleBefore time.Time) (bool, error)
+	GetIdempotencyKey(ctx context.Context, owner string, key string, method string, path string) (models.IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) error
+	DeleteIdempotencyKey(ctx context.Context, owner string, key string, method string, path string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+}
+
+type UserStoreInterface interface {
+	GetUsers(ctx context.Context) ([]models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (models.User, error)
+	HasUsers(ctx context.Context) (bool, error)
+	CreateUser(ctx context.Context, userReq *models.UserRequest, passwordHash string) (models.User, error)
+}
+
+type APIKeyStoreInterface interface {
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	GetAPIKeyById(ctx context.Context, id string) (models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error)
+	CreateAPIKey(ctx context.Context, keyReq *models.APIKeyRequest, prefix string, keyHash string) (models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) (models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
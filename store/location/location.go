@@ -0,0 +1,315 @@
+package location
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+type LocationStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *LocationStore {
+	return &LocationStore{db: db}
+}
+
+func (l LocationStore) GetLocations(ctx context.Context) ([]models.Location, error) {
+	tracer := otel.Tracer("LocationStore")
+	ctx, span := tracer.Start(ctx, "GetLocations-Store")
+	defer span.End()
+	var locations []models.Location
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return locations, err
+	}
+
+	rows, err := l.db.QueryContext(ctx, "SELECT id, parent_id, name, type, address, created_at, updated_at FROM location WHERE tenant_id = $1 ORDER BY name", tenantId)
+	if err != nil {
+		return locations, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var location models.Location
+		err := rows.Scan(&location.ID, &location.ParentID, &location.Name, &location.Type, &location.Address, &location.CreatedAt, &location.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+func (l LocationStore) GetLocationById(ctx context.Context, id string) (models.Location, error) {
+	tracer := otel.Tracer("LocationStore")
+	ctx, span := tracer.Start(ctx, "GetLocationById-Store")
+	defer span.End()
+	var location models.Location
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return location, err
+	}
+
+	err = l.db.QueryRowContext(ctx, "SELECT id, parent_id, name, type, address, created_at, updated_at FROM location WHERE id = $1 AND tenant_id = $2", id, tenantId).Scan(&location.ID, &location.ParentID, &location.Name, &location.Type, &location.Address, &location.CreatedAt, &location.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return location, errors.New("location not found in database")
+		}
+		return location, err
+	}
+	return location, nil
+}
+
+func (l LocationStore) CreateLocation(ctx context.Context, locationReq *models.LocationRequest) (models.Location, error) {
+	tracer := otel.Tracer("LocationStore")
+	ctx, span := tracer.Start(ctx, "CreateLocation-Store")
+	defer span.End()
+	var createdLocation models.Location
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return createdLocation, err
+	}
+
+	createdAt := time.Now()
+	query := `INSERT INTO location (id, tenant_id, parent_id, name, type, address, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, parent_id, name, type, address, created_at, updated_at`
+	err = l.db.QueryRowContext(ctx, query, uuid.New(), tenantId, locationReq.ParentID, locationReq.Name, locationReq.Type, locationReq.Address, createdAt, createdAt).Scan(&createdLocation.ID, &createdLocation.ParentID, &createdLocation.Name, &createdLocation.Type, &createdLocation.Address, &createdLocation.CreatedAt, &createdLocation.UpdatedAt)
+	if err != nil {
+		return createdLocation, err
+	}
+	return createdLocation, nil
+}
+
+func (l LocationStore) UpdateLocation(ctx context.Context, id string, locationReq *models.LocationRequest) (models.Location, error) {
+	tracer := otel.Tracer("LocationStore")
+	ctx, span := tracer.Start(ctx, "UpdateLocation-Store")
+	defer span.End()
+	var updatedLocation models.Location
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return updatedLocation, err
+	}
+
+	query :=
+		`UPDATE location
+		SET parent_id = $1, name = $2, type = $3, address = $4, updated_at = $5
+			WHERE id = $6 AND tenant_id = $7
+				RETURNING id, parent_id, name, type, address, created_at, updated_at`
+	err = l.db.QueryRowContext(ctx, query, locationReq.ParentID, locationReq.Name, locationReq.Type, locationReq.Address, time.Now(), id, tenantId).Scan(&updatedLocation.ID, &updatedLocation.ParentID, &updatedLocation.Name, &updatedLocation.Type, &updatedLocation.Address, &updatedLocation.CreatedAt, &updatedLocation.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return updatedLocation, errors.New("location not found in database")
+		}
+		return updatedLocation, err
+	}
+	return updatedLocation, nil
+}
+
+// HasLots reports whether any location names id as its parent.
+func (l LocationStore) HasLots(ctx context.Context, id string) (bool, error) {
+	tracer := otel.Tracer("LocationStore")
+	ctx, span := tracer.Start(ctx, "HasLots-Store")
+	defer span.End()
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var hasLots bool
+	err = l.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM location WHERE parent_id = $1 AND tenant_id = $2)", id, tenantId).Scan(&hasLots)
+	if err != nil {
+		return false, err
+	}
+	return hasLots, nil
+}
+
+func (l LocationStore) DeleteLocation(ctx context.Context, id string) (models.Location, error) {
+	tracer := otel.Tracer("LocationStore")
+	ctx, span := tracer.Start(ctx, "DeleteLocation-Store")
+	defer span.End()
+	var deletedLocation models.Location
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return deletedLocation, err
+	}
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return deletedLocation, err
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
+			}
+		} else {
+			if commitErr := tx.Commit(); commitErr != nil {
+				logging.FromContext(ctx).Error("Error committing transaction", "error", commitErr)
+			}
+		}
+	}()
+
+	var inUse bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM car WHERE location_id = $1) OR EXISTS (SELECT 1 FROM location WHERE parent_id = $1)", id).Scan(&inUse)
+	if err != nil {
+		return deletedLocation, err
+	}
+	if inUse {
+		err = models.ErrLocationInUse
+		return deletedLocation, err
+	}
+	err = tx.QueryRowContext(ctx, "DELETE FROM location WHERE id = $1 AND tenant_id = $2 RETURNING id, parent_id, name, type, address, created_at, updated_at", id, tenantId).Scan(&deletedLocation.ID, &deletedLocation.ParentID, &deletedLocation.Name, &deletedLocation.Type, &deletedLocation.Address, &deletedLocation.CreatedAt, &deletedLocation.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return deletedLocation, errors.New("location not found in database")
+		}
+		// A car or lot added since the check still holds on to it.
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			err = models.ErrLocationInUse
+		}
+		return deletedLocation, err
+	}
+	return deletedLocation, nil
+}
+
+func (l LocationStore) GetLocationCounts(ctx context.Context) ([]models.LocationCount, error) {
+	tracer := otel.Tracer("LocationStore")
+	ctx, span := tracer.Start(ctx, "GetLocationCounts-Store")
+	defer span.End()
+	var counts []models.LocationCount
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return counts, err
+	}
+
+	query := `WITH RECURSIVE tree AS (
+			SELECT id AS root_id, id FROM location WHERE tenant_id = $1
+			UNION ALL
+			SELECT t.root_id, l.id FROM location l JOIN tree t ON l.parent_id = t.id
+		)
+		SELECT l.id, l.parent_id, l.name, l.type,
+			(SELECT COUNT(*) FROM car c WHERE c.location_id = l.id),
+			(SELECT COUNT(*) FROM car c JOIN tree t ON c.location_id = t.id WHERE t.root_id = l.id)
+		FROM location l WHERE l.tenant_id = $1 ORDER BY l.name`
+	rows, err := l.db.QueryContext(ctx, query, tenantId)
+	if err != nil {
+		return counts, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var count models.LocationCount
+		err := rows.Scan(&count.LocationID, &count.ParentID, &count.Name, &count.Type, &count.Cars, &count.TotalCars)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// TransferCar moves a car to another location and records the movement in
+// the same transaction.
+func (l LocationStore) TransferCar(ctx context.Context, carId string, transferReq *models.CarTransferRequest) (models.CarMovement, error) {
+	tracer := otel.Tracer("LocationStore")
+	ctx, span := tracer.Start(ctx, "TransferCar-Store")
+	defer span.End()
+	var movement models.CarMovement
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return movement, err
+	}
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return movement, err
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
+			}
+		} else {
+			if commitErr := tx.Commit(); commitErr != nil {
+				logging.FromContext(ctx).Error("Error committing transaction", "error", commitErr)
+			}
+		}
+	}()
+
+	var existingCarId uuid.UUID
+	var fromLocationId *uuid.UUID
+	err = tx.QueryRowContext(ctx, "SELECT id, location_id FROM car WHERE id = $1 AND tenant_id = $2 FOR UPDATE", carId, tenantId).Scan(&existingCarId, &fromLocationId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return movement, errors.New("car not found in database")
+		}
+		return movement, err
+	}
+	var toLocationId uuid.UUID
+	err = tx.QueryRowContext(ctx, "SELECT id FROM location WHERE id = $1 AND tenant_id = $2", transferReq.LocationID, tenantId).Scan(&toLocationId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return movement, errors.New("location not found in database")
+		}
+		return movement, err
+	}
+	if fromLocationId != nil && *fromLocationId == toLocationId {
+		err = errors.New("car is already at this location")
+		return movement, err
+	}
+
+	movedAt := time.Now()
+	_, err = tx.ExecContext(ctx, "UPDATE car SET location_id = $1, updated_at = $2 WHERE id = $3", toLocationId, movedAt, existingCarId)
+	if err != nil {
+		return movement, err
+	}
+	query := `INSERT INTO car_movement (id, tenant_id, car_id, from_location_id, to_location_id, reason, moved_by, moved_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, car_id, from_location_id, to_location_id, reason, moved_by, moved_at`
+	err = tx.QueryRowContext(ctx, query, uuid.New(), tenantId, existingCarId, fromLocationId, toLocationId, transferReq.Reason, transferReq.MovedBy, movedAt).Scan(&movement.ID, &movement.CarID, &movement.FromLocationID, &movement.ToLocationID, &movement.Reason, &movement.MovedBy, &movement.MovedAt)
+	if err != nil {
+		return movement, err
+	}
+	return movement, nil
+}
+
+func (l LocationStore) GetCarMovements(ctx context.Context, carId string) ([]models.CarMovement, error) {
+	tracer := otel.Tracer("LocationStore")
+	ctx, span := tracer.Start(ctx, "GetCarMovements-Store")
+	defer span.End()
+	var movements []models.CarMovement
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return movements, err
+	}
+
+	query := `SELECT id, car_id, from_location_id, to_location_id, reason, moved_by, moved_at FROM car_movement WHERE car_id = $1 AND tenant_id = $2 ORDER BY moved_at DESC`
+	rows, err := l.db.QueryContext(ctx, query, carId, tenantId)
+	if err != nil {
+		return movements, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var movement models.CarMovement
+		err := rows.Scan(&movement.ID, &movement.CarID, &movement.FromLocationID, &movement.ToLocationID, &movement.Reason, &movement.MovedBy, &movement.MovedAt)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
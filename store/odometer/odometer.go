@@ -0,0 +1,114 @@
+package odometer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+type OdometerStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *OdometerStore {
+	return &OdometerStore{db: db}
+}
+
+func (o OdometerStore) GetOdometerReadings(ctx context.Context, carId string) ([]models.OdometerReading, error) {
+	tracer := otel.Tracer("OdometerStore")
+	ctx, span := tracer.Start(ctx, "GetOdometerReadings-Store")
+	defer span.End()
+	var readings []models.OdometerReading
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return readings, err
+	}
+
+	query := `SELECT id, car_id, reading, recorded_at, override, override_reason, recorded_by, created_at FROM odometer_reading WHERE car_id = $1 AND tenant_id = $2 ORDER BY recorded_at DESC, created_at DESC`
+	rows, err := o.db.QueryContext(ctx, query, carId, tenantId)
+	if err != nil {
+		return readings, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var reading models.OdometerReading
+		err := rows.Scan(&reading.ID, &reading.CarID, &reading.Reading, &reading.RecordedAt, &reading.Override, &reading.OverrideReason, &reading.RecordedBy, &reading.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		readings = append(readings, reading)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return readings, nil
+}
+
+// CreateOdometerReading stores a reading and makes it the car's current
+// mileage. The car row is locked for the duration of the transaction so
+// concurrent readings are checked against each other.
+func (o OdometerStore) CreateOdometerReading(ctx context.Context, carId string, readingReq *models.OdometerReadingRequest) (models.OdometerReading, error) {
+	tracer := otel.Tracer("OdometerStore")
+	ctx, span := tracer.Start(ctx, "CreateOdometerReading-Store")
+	defer span.End()
+	var createdReading models.OdometerReading
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return createdReading, err
+	}
+
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return createdReading, err
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
+			}
+		} else {
+			if commitErr := tx.Commit(); commitErr != nil {
+				logging.FromContext(ctx).Error("Error committing transaction", "error", commitErr)
+			}
+		}
+	}()
+
+	var existingCarId uuid.UUID
+	var mileage int64
+	err = tx.QueryRowContext(ctx, "SELECT id, mileage FROM car WHERE id = $1 AND tenant_id = $2 FOR UPDATE", carId, tenantId).Scan(&existingCarId, &mileage)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return createdReading, errors.New("car not found in database")
+		}
+		return createdReading, err
+	}
+	if readingReq.Reading < mileage && !readingReq.Override {
+		err = fmt.Errorf("%w (%d)", models.ErrOdometerDecreased, mileage)
+		return createdReading, err
+	}
+
+	createdAt := time.Now()
+	recordedAt := readingReq.RecordedAt
+	if recordedAt.IsZero() {
+		recordedAt = createdAt
+	}
+	query := `INSERT INTO odometer_reading (id, tenant_id, car_id, reading, recorded_at, override, override_reason, recorded_by, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, car_id, reading, recorded_at, override, override_reason, recorded_by, created_at`
+	err = tx.QueryRowContext(ctx, query, uuid.New(), tenantId, existingCarId, readingReq.Reading, recordedAt, readingReq.Override, readingReq.OverrideReason, readingReq.RecordedBy, createdAt).Scan(&createdReading.ID, &createdReading.CarID, &createdReading.Reading, &createdReading.RecordedAt, &createdReading.Override, &createdReading.OverrideReason, &createdReading.RecordedBy, &createdReading.CreatedAt)
+	if err != nil {
+		return createdReading, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE car SET mileage = $1, updated_at = $2 WHERE id = $3", readingReq.Reading, createdAt, existingCarId)
+	if err != nil {
+		return createdReading, err
+	}
+	return createdReading, nil
+}
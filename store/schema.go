@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+)
+
+// Schema creates and upgrades every table. It only uses IF NOT EXISTS
+// statements, so it is safe to run on every start.
+//
+//go:embed schema.sql
+var Schema string
+
+// ErrSchemaNotApplied is returned by CheckSchema when the database has not
+// been migrated to the Schema of this build.
+var ErrSchemaNotApplied = errors.New("schema of this build has not been applied; run carm migrate")
+
+func Migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, Schema); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, "INSERT INTO schema_version (checksum) VALUES ($1) ON CONFLICT DO NOTHING", schemaChecksum())
+	return err
+}
+
+// CheckSchema reports whether Migrate has applied this build's Schema.
+func CheckSchema(ctx context.Context, db *sql.DB) error {
+	var applied bool
+	err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM schema_version WHERE checksum = $1)", schemaChecksum()).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return ErrSchemaNotApplied
+	}
+	return nil
+}
+
+func schemaChecksum() string {
+	sum := sha256.Sum256([]byte(Schema))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,3 +1,16 @@
+-- Create tenant table
+CREATE TABLE IF NOT EXISTS tenant (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Default tenant owning data created before tenants existed
+INSERT INTO tenant (id, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'Default')
+ON CONFLICT (id) DO NOTHING;
+
 -- Create engine table
 CREATE TABLE IF NOT EXISTS engine (
     id UUID PRIMARY KEY,
@@ -21,32 +34,251 @@ CREATE TABLE IF NOT EXISTS car (
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+ALTER TABLE car ADD COLUMN IF NOT EXISTS mileage BIGINT NOT NULL DEFAULT 0;
+
+-- Create location table (dealerships and their lots)
+CREATE TABLE IF NOT EXISTS location (
+    id UUID PRIMARY KEY,
+    parent_id UUID REFERENCES location(id),
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE car ADD COLUMN IF NOT EXISTS location_id UUID REFERENCES location(id);
+
+CREATE INDEX IF NOT EXISTS idx_car_location_id ON car (location_id);
+
 -- Drop existing foreign key constraint (if exists)
 ALTER TABLE IF EXISTS car
 DROP CONSTRAINT IF EXISTS fk_engine_id;
 
--- Add foreign key constraint on engine_id in car table
+-- Add foreign key constraint on engine_id in car table; engines still
+-- used by cars cannot be deleted
 ALTER TABLE car
 ADD CONSTRAINT fk_engine_id
 FOREIGN KEY (engine_id)
 REFERENCES engine(id)
-ON DELETE CASCADE;
-
--- Truncate both tables safely
-TRUNCATE TABLE car, engine RESTART IDENTITY CASCADE;
-
--- Insert dummy data into the engine table
-INSERT INTO engine (id, displacement, no_of_cylinders, car_range)
-VALUES
-    ('e1f86b1a-0873-4c19-bae2-fc60329d0140', 2000, 4, 600),
-    ('f4a9c66b-8e38-419b-93c4-215d5cefb318', 1600, 4, 550),
-    ('cc2c2a7d-2e21-4f59-b7b8-bd9e5e4cf04c', 3000, 6, 700),
-    ('9746be12-07b7-42a3-b8ab-7d1f209b63d7', 1800, 4, 500);
-
--- Insert dummy data into the car table
-INSERT INTO car (id, name, year, brand, fuel_type, engine_id, price)
-VALUES
-    ('c7c1a6d5-1ec4-4c64-a59a-8a2f6f3d2bf3', 'Honda Civic', '2023', 'Honda', 'Gasoline', 'e1f86b1a-0873-4c19-bae2-fc60329d0140', 25000.00),
-    ('9d6a56f8-79c3-4931-a5c0-6b290c84ba2f', 'Toyota Corolla', '2022', 'Toyota', 'Gasoline', 'f4a9c66b-8e38-419b-93c4-215d5cefb318', 22000.00),
-    ('9b9437c4-3ed1-45a5-b240-0fe3e24e0e4e', 'Ford Mustang', '2024', 'Ford', 'Gasoline', 'cc2c2a7d-2e21-4f59-b7b8-bd9e5e4cf04c', 40000.00),
-    ('5e9df51a-8d7a-4d84-9c58-4ccfe5c7db06', 'BMW 3 Series', '2023', 'BMW', 'Gasoline', '9746be12-07b7-42a3-b8ab-7d1f209b63d7', 35000.00);
+ON DELETE RESTRICT;
+
+-- Create service_record table
+CREATE TABLE IF NOT EXISTS service_record (
+    id UUID PRIMARY KEY,
+    car_id UUID NOT NULL REFERENCES car(id) ON DELETE CASCADE,
+    date TIMESTAMP NOT NULL,
+    odometer BIGINT NOT NULL,
+    work_type VARCHAR(50) NOT NULL,
+    cost DECIMAL(10, 2) NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_record_car_id ON service_record (car_id, date DESC);
+
+-- Create odometer_reading table
+CREATE TABLE IF NOT EXISTS odometer_reading (
+    id UUID PRIMARY KEY,
+    car_id UUID NOT NULL REFERENCES car(id) ON DELETE CASCADE,
+    reading BIGINT NOT NULL CHECK (reading >= 0),
+    recorded_at TIMESTAMP NOT NULL,
+    override BOOLEAN NOT NULL DEFAULT FALSE,
+    override_reason TEXT NOT NULL DEFAULT '',
+    recorded_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_odometer_reading_car_id ON odometer_reading (car_id, recorded_at DESC);
+
+-- Create attachment table
+CREATE TABLE IF NOT EXISTS attachment (
+    id UUID PRIMARY KEY,
+    car_id UUID NOT NULL REFERENCES car(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(512) NOT NULL,
+    thumbnail_key VARCHAR(512) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachment_car_id ON attachment (car_id);
+
+-- Create car_movement table
+CREATE TABLE IF NOT EXISTS car_movement (
+    id UUID PRIMARY KEY,
+    car_id UUID NOT NULL REFERENCES car(id) ON DELETE CASCADE,
+    from_location_id UUID REFERENCES location(id),
+    to_location_id UUID NOT NULL REFERENCES location(id),
+    reason TEXT NOT NULL DEFAULT '',
+    moved_by VARCHAR(255) NOT NULL DEFAULT '',
+    moved_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_car_movement_car_id ON car_movement (car_id, moved_at DESC);
+
+-- Movements outlive the locations they name; deleting a location clears
+-- the reference. Only changed once, so that starts take no table locks.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'car_movement_to_location_id_fkey' AND confdeltype <> 'n') THEN
+        ALTER TABLE car_movement ALTER COLUMN to_location_id DROP NOT NULL;
+        ALTER TABLE car_movement DROP CONSTRAINT car_movement_from_location_id_fkey;
+        ALTER TABLE car_movement DROP CONSTRAINT car_movement_to_location_id_fkey;
+        ALTER TABLE car_movement ADD CONSTRAINT car_movement_from_location_id_fkey FOREIGN KEY (from_location_id) REFERENCES location(id) ON DELETE SET NULL;
+        ALTER TABLE car_movement ADD CONSTRAINT car_movement_to_location_id_fkey FOREIGN KEY (to_location_id) REFERENCES location(id) ON DELETE SET NULL;
+    END IF;
+END $$;
+
+-- Scope every table to a tenant
+ALTER TABLE engine ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenant(id);
+ALTER TABLE car ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenant(id);
+ALTER TABLE service_record ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenant(id);
+ALTER TABLE odometer_reading ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenant(id);
+ALTER TABLE attachment ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenant(id);
+ALTER TABLE location ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenant(id);
+ALTER TABLE car_movement ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenant(id);
+
+CREATE INDEX IF NOT EXISTS idx_engine_tenant_id ON engine (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_car_tenant_id ON car (tenant_id, brand);
+CREATE INDEX IF NOT EXISTS idx_location_tenant_id ON location (tenant_id);
+
+-- Create webhook tables; deliveries double as the persistent retry queue
+CREATE TABLE IF NOT EXISTS webhook (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenant(id),
+    url TEXT NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    secret VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_tenant_id ON webhook (tenant_id);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenant(id),
+    webhook_id UUID NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    response_status INT,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_webhook_id ON webhook_delivery (webhook_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_due ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+
+-- Event keys let a relayed event be queued twice without a second delivery
+ALTER TABLE webhook_delivery ADD COLUMN IF NOT EXISTS event_key VARCHAR(64) NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_delivery_event_key ON webhook_delivery (webhook_id, event_key) WHERE event_key <> '';
+
+-- Create outbox table; events are written in the same transaction as the
+-- change they describe and relayed to the sinks afterwards
+CREATE TABLE IF NOT EXISTS outbox (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenant(id),
+    entity VARCHAR(50) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    brand VARCHAR(255) NOT NULL DEFAULT '',
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    published_at TIMESTAMP,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT ''
+);
+
+-- Sinks that accepted a message, so that each sink relays at its own pace
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS sent_sinks TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox (created_at, id) WHERE published_at IS NULL;
+
+-- Create idempotency_key table; responses to create requests sent with an
+-- Idempotency-Key header are replayed from here until they expire
+CREATE TABLE IF NOT EXISTS idempotency_key (
+    tenant_id UUID NOT NULL REFERENCES tenant(id),
+    key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(512) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    body BYTEA NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (tenant_id, key, method, path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_key_expires_at ON idempotency_key (expires_at);
+
+-- Scope idempotency keys to the user or API key that sent them
+ALTER TABLE idempotency_key ADD COLUMN IF NOT EXISTS owner VARCHAR(255) NOT NULL DEFAULT '';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint c
+        JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = ANY (c.conkey)
+        WHERE c.conname = 'idempotency_key_pkey' AND c.conrelid = 'idempotency_key'::regclass AND a.attname = 'owner'
+    ) THEN
+        ALTER TABLE idempotency_key DROP CONSTRAINT IF EXISTS idempotency_key_pkey;
+        ALTER TABLE idempotency_key ADD CONSTRAINT idempotency_key_pkey PRIMARY KEY (tenant_id, owner, key, method, path);
+    END IF;
+END $$;
+
+-- Create app_user table; users sign in with a PBKDF2-hashed password and
+-- act for the tenant they belong to
+CREATE TABLE IF NOT EXISTS app_user (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenant(id),
+    username VARCHAR(255) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (tenant_id, username)
+);
+
+-- Create api_key table; machine clients authenticate with a key whose
+-- SHA-256 hash is kept here. Revoked keys stay for the record
+CREATE TABLE IF NOT EXISTS api_key (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenant(id),
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_key_tenant_id ON api_key (tenant_id, created_at);
+
+-- Create rate_limit_bucket table; the token buckets of the postgres rate
+-- limit backend, removed once they have refilled
+CREATE TABLE IF NOT EXISTS rate_limit_bucket (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP,
+    full_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_bucket_full_at ON rate_limit_bucket (full_at);
+
+-- Create schema_version table; Migrate records the checksum of the schema
+-- it applied, so readiness checks can tell whether this build's schema is
+-- in place
+CREATE TABLE IF NOT EXISTS schema_version (
+    checksum VARCHAR(64) PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
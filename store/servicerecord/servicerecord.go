@@ -0,0 +1,193 @@
+package servicerecord
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+type ServiceRecordStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *ServiceRecordStore {
+	return &ServiceRecordStore{db: db}
+}
+
+func (s ServiceRecordStore) GetServiceRecords(ctx context.Context, carId string) ([]models.ServiceRecord, error) {
+	tracer := otel.Tracer("ServiceRecordStore")
+	ctx, span := tracer.Start(ctx, "GetServiceRecords-Store")
+	defer span.End()
+	var records []models.ServiceRecord
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return records, err
+	}
+
+	query := `SELECT id, car_id, date, odometer, work_type, cost, notes, created_at, updated_at FROM service_record WHERE car_id = $1 AND tenant_id = $2 ORDER BY date DESC, odometer DESC`
+	rows, err := s.db.QueryContext(ctx, query, carId, tenantId)
+	if err != nil {
+		return records, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var record models.ServiceRecord
+		err := rows.Scan(&record.ID, &record.CarID, &record.Date, &record.Odometer, &record.WorkType, &record.Cost, &record.Notes, &record.CreatedAt, &record.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s ServiceRecordStore) GetServiceRecordById(ctx context.Context, carId string, id string) (models.ServiceRecord, error) {
+	tracer := otel.Tracer("ServiceRecordStore")
+	ctx, span := tracer.Start(ctx, "GetServiceRecordById-Store")
+	defer span.End()
+	var record models.ServiceRecord
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return record, err
+	}
+
+	query := `SELECT id, car_id, date, odometer, work_type, cost, notes, created_at, updated_at FROM service_record WHERE car_id = $1 AND id = $2 AND tenant_id = $3`
+	err = s.db.QueryRowContext(ctx, query, carId, id, tenantId).Scan(&record.ID, &record.CarID, &record.Date, &record.Odometer, &record.WorkType, &record.Cost, &record.Notes, &record.CreatedAt, &record.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return record, errors.New("service record not found in database")
+		}
+		return record, err
+	}
+	return record, nil
+}
+
+func (s ServiceRecordStore) CreateServiceRecord(ctx context.Context, carId string, recordReq *models.ServiceRecordRequest) (models.ServiceRecord, error) {
+	tracer := otel.Tracer("ServiceRecordStore")
+	ctx, span := tracer.Start(ctx, "CreateServiceRecord-Store")
+	defer span.End()
+	var createdRecord models.ServiceRecord
+
+	var existingCarId uuid.UUID
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return createdRecord, err
+	}
+	err = s.db.QueryRowContext(ctx, "SELECT id FROM car WHERE id = $1 AND tenant_id = $2", carId, tenantId).Scan(&existingCarId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return createdRecord, errors.New("car not found in database")
+		}
+		return createdRecord, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return createdRecord, err
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
+			}
+		} else {
+			if commitErr := tx.Commit(); commitErr != nil {
+				logging.FromContext(ctx).Error("Error committing transaction", "error", commitErr)
+			}
+		}
+	}()
+
+	createdAt := time.Now()
+	query := `INSERT INTO service_record (id, tenant_id, car_id, date, odometer, work_type, cost, notes, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, car_id, date, odometer, work_type, cost, notes, created_at, updated_at`
+	err = tx.QueryRowContext(ctx, query, uuid.New(), tenantId, existingCarId, recordReq.Date, recordReq.Odometer, recordReq.WorkType, recordReq.Cost, recordReq.Notes, createdAt, createdAt).Scan(&createdRecord.ID, &createdRecord.CarID, &createdRecord.Date, &createdRecord.Odometer, &createdRecord.WorkType, &createdRecord.Cost, &createdRecord.Notes, &createdRecord.CreatedAt, &createdRecord.UpdatedAt)
+	if err != nil {
+		return createdRecord, err
+	}
+	return createdRecord, nil
+}
+
+func (s ServiceRecordStore) UpdateServiceRecord(ctx context.Context, carId string, id string, recordReq *models.ServiceRecordRequest) (models.ServiceRecord, error) {
+	tracer := otel.Tracer("ServiceRecordStore")
+	ctx, span := tracer.Start(ctx, "UpdateServiceRecord-Store")
+	defer span.End()
+	var updatedRecord models.ServiceRecord
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return updatedRecord, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return updatedRecord, err
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
+			}
+		} else {
+			if commitErr := tx.Commit(); commitErr != nil {
+				logging.FromContext(ctx).Error("Error committing transaction", "error", commitErr)
+			}
+		}
+	}()
+	query :=
+		`UPDATE service_record
+		SET date = $1, odometer = $2, work_type = $3, cost = $4, notes = $5, updated_at = $6
+			WHERE car_id = $7 AND id = $8 AND tenant_id = $9
+				RETURNING id, car_id, date, odometer, work_type, cost, notes, created_at, updated_at`
+	err = tx.QueryRowContext(ctx, query, recordReq.Date, recordReq.Odometer, recordReq.WorkType, recordReq.Cost, recordReq.Notes, time.Now(), carId, id, tenantId).Scan(&updatedRecord.ID, &updatedRecord.CarID, &updatedRecord.Date, &updatedRecord.Odometer, &updatedRecord.WorkType, &updatedRecord.Cost, &updatedRecord.Notes, &updatedRecord.CreatedAt, &updatedRecord.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return updatedRecord, errors.New("service record not found in database")
+		}
+		return updatedRecord, err
+	}
+	return updatedRecord, nil
+}
+
+func (s ServiceRecordStore) DeleteServiceRecord(ctx context.Context, carId string, id string) (models.ServiceRecord, error) {
+	tracer := otel.Tracer("ServiceRecordStore")
+	ctx, span := tracer.Start(ctx, "DeleteServiceRecord-Store")
+	defer span.End()
+	var deletedRecord models.ServiceRecord
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return deletedRecord, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return deletedRecord, err
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
+			}
+		} else {
+			if commitErr := tx.Commit(); commitErr != nil {
+				logging.FromContext(ctx).Error("Error committing transaction", "error", commitErr)
+			}
+		}
+	}()
+	query := `DELETE FROM service_record WHERE car_id = $1 AND id = $2 AND tenant_id = $3 RETURNING id, car_id, date, odometer, work_type, cost, notes, created_at, updated_at`
+	err = tx.QueryRowContext(ctx, query, carId, id, tenantId).Scan(&deletedRecord.ID, &deletedRecord.CarID, &deletedRecord.Date, &deletedRecord.Odometer, &deletedRecord.WorkType, &deletedRecord.Cost, &deletedRecord.Notes, &deletedRecord.CreatedAt, &deletedRecord.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return deletedRecord, errors.New("service record not found in database")
+		}
+		return deletedRecord, err
+	}
+	return deletedRecord, nil
+}
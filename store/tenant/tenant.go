@@ -0,0 +1,110 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+// TenantStore manages the tenants themselves and is therefore the one store
+// that is not scoped to the tenant in the request context.
+type TenantStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *TenantStore {
+	return &TenantStore{db: db}
+}
+
+func (t TenantStore) GetTenants(ctx context.Context) ([]models.Tenant, error) {
+	tracer := otel.Tracer("TenantStore")
+	ctx, span := tracer.Start(ctx, "GetTenants-Store")
+	defer span.End()
+	var tenants []models.Tenant
+
+	rows, err := t.db.QueryContext(ctx, "SELECT id, name, created_at, updated_at FROM tenant ORDER BY name")
+	if err != nil {
+		return tenants, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tenant models.Tenant
+		if err := rows.Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt, &tenant.UpdatedAt); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
+
+func (t TenantStore) GetTenantById(ctx context.Context, id string) (models.Tenant, error) {
+	tracer := otel.Tracer("TenantStore")
+	ctx, span := tracer.Start(ctx, "GetTenantById-Store")
+	defer span.End()
+	var tenant models.Tenant
+
+	err := t.db.QueryRowContext(ctx, "SELECT id, name, created_at, updated_at FROM tenant WHERE id = $1", id).Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt, &tenant.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return tenant, models.ErrTenantNotFound
+		}
+		return tenant, err
+	}
+	return tenant, nil
+}
+
+func (t TenantStore) CreateTenant(ctx context.Context, tenantReq *models.TenantRequest) (models.Tenant, error) {
+	tracer := otel.Tracer("TenantStore")
+	ctx, span := tracer.Start(ctx, "CreateTenant-Store")
+	defer span.End()
+	var tenant models.Tenant
+
+	createdAt := time.Now()
+	err := t.db.QueryRowContext(ctx, "INSERT INTO tenant (id, name, created_at, updated_at) VALUES ($1, $2, $3, $4) RETURNING id, name, created_at, updated_at", uuid.New(), tenantReq.Name, createdAt, createdAt).Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt, &tenant.UpdatedAt)
+	if err != nil {
+		return tenant, err
+	}
+	return tenant, nil
+}
+
+func (t TenantStore) UpdateTenant(ctx context.Context, id string, tenantReq *models.TenantRequest) (models.Tenant, error) {
+	tracer := otel.Tracer("TenantStore")
+	ctx, span := tracer.Start(ctx, "UpdateTenant-Store")
+	defer span.End()
+	var tenant models.Tenant
+
+	err := t.db.QueryRowContext(ctx, "UPDATE tenant SET name = $1, updated_at = $2 WHERE id = $3 RETURNING id, name, created_at, updated_at", tenantReq.Name, time.Now(), id).Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt, &tenant.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return tenant, models.ErrTenantNotFound
+		}
+		return tenant, err
+	}
+	return tenant, nil
+}
+
+// DeleteTenant removes an empty tenant; the foreign keys from the tenant
+// scoped tables make it fail while the tenant still owns any data.
+func (t TenantStore) DeleteTenant(ctx context.Context, id string) (models.Tenant, error) {
+	tracer := otel.Tracer("TenantStore")
+	ctx, span := tracer.Start(ctx, "DeleteTenant-Store")
+	defer span.End()
+	var tenant models.Tenant
+
+	err := t.db.QueryRowContext(ctx, "DELETE FROM tenant WHERE id = $1 RETURNING id, name, created_at, updated_at", id).Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt, &tenant.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return tenant, models.ErrTenantNotFound
+		}
+		return tenant, err
+	}
+	return tenant, nil
+}
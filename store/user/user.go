@@ -0,0 +1,100 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+type UserStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *UserStore {
+	return &UserStore{db: db}
+}
+
+func (u UserStore) GetUsers(ctx context.Context) ([]models.User, error) {
+	tracer := otel.Tracer("UserStore")
+	ctx, span := tracer.Start(ctx, "GetUsers-Store")
+	defer span.End()
+	var users []models.User
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return users, err
+	}
+
+	rows, err := u.db.QueryContext(ctx, "SELECT id, tenant_id, username, role, password_hash, created_at FROM app_user WHERE tenant_id = $1 ORDER BY username", tenantId)
+	if err != nil {
+		return users, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.TenantID, &user.Username, &user.Role, &user.PasswordHash, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetUserByUsername returns a zero User when the tenant has no such user.
+func (u UserStore) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	tracer := otel.Tracer("UserStore")
+	ctx, span := tracer.Start(ctx, "GetUserByUsername-Store")
+	defer span.End()
+	var user models.User
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return user, err
+	}
+
+	err = u.db.QueryRowContext(ctx, "SELECT id, tenant_id, username, role, password_hash, created_at FROM app_user WHERE tenant_id = $1 AND username = $2", tenantId, username).Scan(&user.ID, &user.TenantID, &user.Username, &user.Role, &user.PasswordHash, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, nil
+		}
+		return user, err
+	}
+	return user, nil
+}
+
+// HasUsers reports whether any tenant has a user.
+func (u UserStore) HasUsers(ctx context.Context) (bool, error) {
+	tracer := otel.Tracer("UserStore")
+	ctx, span := tracer.Start(ctx, "HasUsers-Store")
+	defer span.End()
+
+	var exists bool
+	err := u.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM app_user)").Scan(&exists)
+	return exists, err
+}
+
+func (u UserStore) CreateUser(ctx context.Context, userReq *models.UserRequest, passwordHash string) (models.User, error) {
+	tracer := otel.Tracer("UserStore")
+	ctx, span := tracer.Start(ctx, "CreateUser-Store")
+	defer span.End()
+	var user models.User
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return user, err
+	}
+
+	query := `INSERT INTO app_user (id, tenant_id, username, password_hash, role, created_at) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, tenant_id, username, role, password_hash, created_at`
+	err = u.db.QueryRowContext(ctx, query, uuid.New(), tenantId, userReq.Username, passwordHash, userReq.Role, time.Now()).Scan(&user.ID, &user.TenantID, &user.Username, &user.Role, &user.PasswordHash, &user.CreatedAt)
+	if err != nil {
+		return user, err
+	}
+	return user, nil
+}
@@ -0,0 +1,297 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Akmyrat17/carm/logging"
+	"github.com/Akmyrat17/carm/models"
+	"github.com/Akmyrat17/carm/tenant"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+type WebhookStore struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *WebhookStore {
+	return &WebhookStore{db: db}
+}
+
+func (s WebhookStore) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	tracer := otel.Tracer("WebhookStore")
+	ctx, span := tracer.Start(ctx, "GetWebhooks-Store")
+	defer span.End()
+	var webhooks []models.Webhook
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return webhooks, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, url, events, active, created_at, updated_at FROM webhook WHERE tenant_id = $1 ORDER BY created_at", tenantId)
+	if err != nil {
+		return webhooks, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var webhook models.Webhook
+		err := rows.Scan(&webhook.ID, &webhook.URL, pq.Array(&webhook.Events), &webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (s WebhookStore) GetWebhookById(ctx context.Context, id string) (models.Webhook, error) {
+	tracer := otel.Tracer("WebhookStore")
+	ctx, span := tracer.Start(ctx, "GetWebhookById-Store")
+	defer span.End()
+	var webhook models.Webhook
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return webhook, err
+	}
+
+	err = s.db.QueryRowContext(ctx, "SELECT id, url, events, active, created_at, updated_at FROM webhook WHERE id = $1 AND tenant_id = $2", id, tenantId).Scan(&webhook.ID, &webhook.URL, pq.Array(&webhook.Events), &webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return webhook, errors.New("webhook not found in database")
+		}
+		return webhook, err
+	}
+	return webhook, nil
+}
+
+func (s WebhookStore) CreateWebhook(ctx context.Context, webhookReq *models.WebhookRequest, secret string) (models.Webhook, error) {
+	tracer := otel.Tracer("WebhookStore")
+	ctx, span := tracer.Start(ctx, "CreateWebhook-Store")
+	defer span.End()
+	var createdWebhook models.Webhook
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return createdWebhook, err
+	}
+
+	active := true
+	if webhookReq.Active != nil {
+		active = *webhookReq.Active
+	}
+	createdAt := time.Now()
+	query := `INSERT INTO webhook (id, tenant_id, url, events, active, secret, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, url, events, active, secret, created_at, updated_at`
+	err = s.db.QueryRowContext(ctx, query, uuid.New(), tenantId, webhookReq.URL, pq.Array(eventList(webhookReq.Events)), active, secret, createdAt, createdAt).Scan(&createdWebhook.ID, &createdWebhook.URL, pq.Array(&createdWebhook.Events), &createdWebhook.Active, &createdWebhook.Secret, &createdWebhook.CreatedAt, &createdWebhook.UpdatedAt)
+	if err != nil {
+		return createdWebhook, err
+	}
+	return createdWebhook, nil
+}
+
+func (s WebhookStore) UpdateWebhook(ctx context.Context, id string, webhookReq *models.WebhookRequest) (models.Webhook, error) {
+	tracer := otel.Tracer("WebhookStore")
+	ctx, span := tracer.Start(ctx, "UpdateWebhook-Store")
+	defer span.End()
+	var updatedWebhook models.Webhook
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return updatedWebhook, err
+	}
+
+	query :=
+		`UPDATE webhook
+		SET url = $1, events = $2, active = COALESCE($3, active), updated_at = $4
+			WHERE id = $5 AND tenant_id = $6
+				RETURNING id, url, events, active, created_at, updated_at`
+	err = s.db.QueryRowContext(ctx, query, webhookReq.URL, pq.Array(eventList(webhookReq.Events)), webhookReq.Active, time.Now(), id, tenantId).Scan(&updatedWebhook.ID, &updatedWebhook.URL, pq.Array(&updatedWebhook.Events), &updatedWebhook.Active, &updatedWebhook.CreatedAt, &updatedWebhook.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return updatedWebhook, errors.New("webhook not found in database")
+		}
+		return updatedWebhook, err
+	}
+	return updatedWebhook, nil
+}
+
+func (s WebhookStore) DeleteWebhook(ctx context.Context, id string) (models.Webhook, error) {
+	tracer := otel.Tracer("WebhookStore")
+	ctx, span := tracer.Start(ctx, "DeleteWebhook-Store")
+	defer span.End()
+	var deletedWebhook models.Webhook
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return deletedWebhook, err
+	}
+
+	err = s.db.QueryRowContext(ctx, "DELETE FROM webhook WHERE id = $1 AND tenant_id = $2 RETURNING id, url, events, active, created_at, updated_at", id, tenantId).Scan(&deletedWebhook.ID, &deletedWebhook.URL, pq.Array(&deletedWebhook.Events), &deletedWebhook.Active, &deletedWebhook.CreatedAt, &deletedWebhook.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return deletedWebhook, errors.New("webhook not found in database")
+		}
+		return deletedWebhook, err
+	}
+	return deletedWebhook, nil
+}
+
+// GetSubscribedWebhooks returns the active webhooks of the tenant that
+// listen to eventType, together with their secrets.
+func (s WebhookStore) GetSubscribedWebhooks(ctx context.Context, eventType string) ([]models.Webhook, error) {
+	tracer := otel.Tracer("WebhookStore")
+	ctx, span := tracer.Start(ctx, "GetSubscribedWebhooks-Store")
+	defer span.End()
+	var webhooks []models.Webhook
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return webhooks, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, url, events, active, secret, created_at, updated_at FROM webhook WHERE tenant_id = $1 AND active AND (cardinality(events) = 0 OR $2 = ANY(events))", tenantId, eventType)
+	if err != nil {
+		return webhooks, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var webhook models.Webhook
+		err := rows.Scan(&webhook.ID, &webhook.URL, pq.Array(&webhook.Events), &webhook.Active, &webhook.Secret, &webhook.CreatedAt, &webhook.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (s WebhookStore) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	tracer := otel.Tracer("WebhookStore")
+	ctx, span := tracer.Start(ctx, "CreateDeliveries-Store")
+	defer span.End()
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				logging.FromContext(ctx).Error("Error rolling back transaction", "error", rbErr)
+			}
+		} else {
+			if commitErr := tx.Commit(); commitErr != nil {
+				logging.FromContext(ctx).Error("Error committing transaction", "error", commitErr)
+			}
+		}
+	}()
+	for _, delivery := range deliveries {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO webhook_delivery (id, tenant_id, webhook_id, event_type, event_key, payload, status, next_attempt_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+				ON CONFLICT (webhook_id, event_key) WHERE event_key <> '' DO NOTHING`,
+			delivery.ID, tenantId, delivery.WebhookID, delivery.EventType, delivery.EventKey, []byte(delivery.Payload), delivery.Status, delivery.NextAttemptAt, delivery.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s WebhookStore) GetDeliveries(ctx context.Context, webhookId string) ([]models.WebhookDelivery, error) {
+	tracer := otel.Tracer("WebhookStore")
+	ctx, span := tracer.Start(ctx, "GetDeliveries-Store")
+	defer span.End()
+	var deliveries []models.WebhookDelivery
+	tenantId, err := tenant.FromContext(ctx)
+	if err != nil {
+		return deliveries, err
+	}
+
+	query := `SELECT id, webhook_id, event_type, event_key, payload, status, attempts, response_status, last_error, next_attempt_at, created_at, delivered_at
+		FROM webhook_delivery WHERE webhook_id = $1 AND tenant_id = $2 ORDER BY created_at DESC LIMIT 100`
+	rows, err := s.db.QueryContext(ctx, query, webhookId, tenantId)
+	if err != nil {
+		return deliveries, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventType, &delivery.EventKey, &delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.ResponseStatus, &delivery.LastError, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.DeliveredAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDeliveries picks up to limit pending deliveries of all tenants that
+// are due and pushes their next attempt out by lease, so that concurrent
+// workers do not send the same delivery while it is in flight.
+func (s WebhookStore) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	tracer := otel.Tracer("WebhookStore")
+	ctx, span := tracer.Start(ctx, "ClaimDeliveries-Store")
+	defer span.End()
+	var deliveries []models.WebhookDelivery
+
+	query := `UPDATE webhook_delivery d SET next_attempt_at = $1
+		FROM webhook w
+			WHERE d.webhook_id = w.id AND d.id IN (
+				SELECT id FROM webhook_delivery
+					WHERE status = $2 AND next_attempt_at <= $3
+					ORDER BY next_attempt_at LIMIT $4
+					FOR UPDATE SKIP LOCKED)
+				RETURNING d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.created_at, w.url, w.secret`
+	now := time.Now()
+	rows, err := s.db.QueryContext(ctx, query, now.Add(lease), models.WebhookDeliveryPending, now, limit)
+	if err != nil {
+		return deliveries, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventType, &delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.CreatedAt, &delivery.URL, &delivery.Secret)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery records the outcome of a delivery attempt.
+func (s WebhookStore) UpdateDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	tracer := otel.Tracer("WebhookStore")
+	ctx, span := tracer.Start(ctx, "UpdateDelivery-Store")
+	defer span.End()
+
+	query :=
+		`UPDATE webhook_delivery
+		SET status = $1, attempts = $2, response_status = $3, last_error = $4, next_attempt_at = $5, delivered_at = $6
+			WHERE id = $7`
+	_, err := s.db.ExecContext(ctx, query, delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.LastError, delivery.NextAttemptAt, delivery.DeliveredAt, delivery.ID)
+	return err
+}
+
+// eventList stores "all events" as an empty array rather than NULL.
+func eventList(events []string) []string {
+	if events == nil {
+		return []string{}
+	}
+	return events
+}
@@ -0,0 +1,149 @@
+// Package telemetry sets up the OpenTelemetry traces and metrics of the
+// server. Traces go to the configured exporter; metrics, such as the
+// database/sql and gRPC instruments, are served on /metrics next to the
+// Prometheus ones.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+const (
+	ExporterOTLPHTTP = "otlp-http"
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterStdout   = "stdout"
+	ExporterNone     = "none"
+
+	DefaultOTLPHTTPEndpoint = "localhost:4318"
+	DefaultOTLPGRPCEndpoint = "localhost:4317"
+
+	serviceName = "carm"
+)
+
+// Config selects where traces are sent and how many are recorded.
+type Config struct {
+	// Exporter is otlp-http, the default, otlp-grpc, stdout or none.
+	Exporter string
+	// Endpoint is the host:port of the OTLP collector; empty uses the
+	// default port of the exporter.
+	Endpoint string
+	// SampleRatio is the share of new traces that are recorded, from 0 to
+	// 1. Requests that arrive with a trace header follow the decision of
+	// the caller instead.
+	SampleRatio float64
+}
+
+// ValidExporter reports whether name is a known exporter.
+func ValidExporter(name string) bool {
+	switch name {
+	case ExporterOTLPHTTP, ExporterOTLPGRPC, ExporterStdout, ExporterNone:
+		return true
+	}
+	return false
+}
+
+// OTLP reports whether traces are sent to a collector at Address.
+func (c Config) OTLP() bool {
+	return c.Exporter == "" || c.Exporter == ExporterOTLPHTTP || c.Exporter == ExporterOTLPGRPC
+}
+
+// Address returns the collector endpoint, or the default one of the
+// exporter.
+func (c Config) Address() string {
+	switch {
+	case c.Endpoint != "":
+		return c.Endpoint
+	case c.Exporter == ExporterOTLPGRPC:
+		return DefaultOTLPGRPCEndpoint
+	default:
+		return DefaultOTLPHTTPEndpoint
+	}
+}
+
+// Providers are the tracer and meter providers of the server.
+type Providers struct {
+	Tracer *sdktrace.TracerProvider
+	Meter  *sdkmetric.MeterProvider
+}
+
+// Start builds the providers for cfg. The meter provider registers its
+// metrics with the default Prometheus registry, so Start is called once.
+func Start(ctx context.Context, cfg Config) (*Providers, error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceNameKey.String(serviceName),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating resource: %w", err)
+	}
+
+	traceOptions := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating new Exporter: %w", err)
+	}
+	// Without an exporter spans are still started, so that the logs keep
+	// their trace and span ids.
+	if exporter != nil {
+		traceOptions = append(traceOptions, sdktrace.WithBatcher(
+			exporter,
+			sdktrace.WithMaxExportBatchSize(sdktrace.DefaultMaxExportBatchSize),
+			sdktrace.WithBatchTimeout(sdktrace.DefaultScheduleDelay),
+		))
+	}
+
+	reader, err := otelprometheus.New()
+	if err != nil {
+		return nil, fmt.Errorf("Error creating metric exporter: %w", err)
+	}
+
+	return &Providers{
+		Tracer: sdktrace.NewTracerProvider(traceOptions...),
+		Meter: sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(reader),
+			sdkmetric.WithResource(res),
+		),
+	}, nil
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case "", ExporterOTLPHTTP:
+		return otlptracehttp.New(ctx,
+			otlptracehttp.WithEndpoint(cfg.Address()),
+			otlptracehttp.WithInsecure(),
+		)
+	case ExporterOTLPGRPC:
+		return otlptracegrpc.New(ctx,
+			otlptracegrpc.WithEndpoint(cfg.Address()),
+			otlptracegrpc.WithInsecure(),
+		)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+}
+
+// Shutdown flushes the remaining spans and stops both providers.
+func (p *Providers) Shutdown(ctx context.Context) error {
+	return errors.Join(p.Tracer.Shutdown(ctx), p.Meter.Shutdown(ctx))
+}
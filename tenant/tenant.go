@@ -0,0 +1,30 @@
+// Package tenant carries the organisation a request acts for through
+// context so that every store can scope its queries to it.
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// Default owns all data created before tenants were introduced and is used
+// for tokens that carry no tenant claim.
+var Default = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+var ErrMissing = errors.New("no tenant in request context")
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+func FromContext(ctx context.Context) (uuid.UUID, error) {
+	id, ok := ctx.Value(contextKey{}).(uuid.UUID)
+	if !ok || id == uuid.Nil {
+		return uuid.Nil, ErrMissing
+	}
+	return id, nil
+}